@@ -8,10 +8,12 @@ import (
 	"strconv"
 )
 
+// versesListsFileName is where verse lists were kept before they moved to the storage.
+// It is only read to import them.
 const versesListsFileName = "versesLists.json"
 
 var bible Bible
-var versesLists []VersesList
+var bibles = make(map[Language]*Bible)
 
 type Verse string
 type Chapter []Verse
@@ -30,14 +32,16 @@ type LongVerse struct {
 	Verses  []int `json:"verses"`
 }
 
+// VersesList can be read by everyone if it is public, otherwise by its owner,
+// writers and readers. Only the owner and writers can edit it.
 type VersesList struct {
-	Id      int
+	Id      int         `json:"id"`
 	Title   string      `json:"title"`
 	List    []LongVerse `json:"list"`
-	OwnerId int64
-	Writers []int64
-	Readers []int64
-	Public  bool
+	OwnerId int64       `json:"ownerId"`
+	Writers []int64     `json:"writers"`
+	Readers []int64     `json:"readers"`
+	Public  bool        `json:"public"`
 }
 
 type VersesListFile struct {
@@ -45,29 +49,30 @@ type VersesListFile struct {
 }
 
 func getBibleFromFile() {
-	fi, err := os.Open("bible.json")
-	if err != nil {
-		panic(err)
-	}
-	defer func() {
-		if err := fi.Close(); err != nil {
-			panic(err)
+	bible = readBibleFile("bible.json")
+	bibles[defaultLanguage] = &bible
+	for _, lang := range languages {
+		if lang == defaultLanguage {
+			continue
 		}
-	}()
-
-	b, err := io.ReadAll(fi)
-	if err != nil {
-		panic(err)
+		fileName := "bible_" + string(lang) + ".json"
+		if _, err := os.Stat(fileName); err != nil {
+			continue
+		}
+		translation := readBibleFile(fileName)
+		bibles[lang] = &translation
 	}
+}
 
-	err = json.Unmarshal(b, &bible)
-	if err != nil {
-		panic(err)
+func bibleForLanguage(lang Language) *Bible {
+	if b, ok := bibles[lang]; ok {
+		return b
 	}
+	return &bible
 }
 
-func getVersesListsFromFile() {
-	fi, err := os.Open(versesListsFileName)
+func readBibleFile(fileName string) Bible {
+	fi, err := os.Open(fileName)
 	if err != nil {
 		panic(err)
 	}
@@ -82,33 +87,25 @@ func getVersesListsFromFile() {
 		panic(err)
 	}
 
-	var versesListsFile VersesListFile
-	err = json.Unmarshal(b, &versesListsFile)
+	var result Bible
+	err = json.Unmarshal(b, &result)
 	if err != nil {
 		panic(err)
 	}
-
-	versesLists = versesListsFile.Lists
+	return result
 }
 
-func saveVersesListsToFile() error {
-	fo, err := os.Create(versesListsFileName)
+func readVersesListsFile() ([]VersesList, error) {
+	b, err := os.ReadFile(versesListsFileName)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	data := VersesListFile{versesLists}
-	b, err := json.Marshal(data)
-	if err != nil {
-		return err
-	}
-
-	_, err = fo.Write(b)
+	var versesListsFile VersesListFile
+	err = json.Unmarshal(b, &versesListsFile)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	return nil
+	return versesListsFile.Lists, nil
 }
 
 func (bible *Bible) getVerse(book int, chapter int, verse int) string {
@@ -123,7 +120,7 @@ func (bible *Bible) getRandomVerse() string {
 	return formatResult(string(chapter[verseNum]), book.ShortTitle, chapterNum+1, []int{verseNum + 1})
 }
 
-func (list *VersesList) getRandomVerse() string {
+func (list *VersesList) getRandomVerse(bible *Bible) string {
 	longVerse := list.List[rand.Intn(len(list.List))]
 	result := bible.getVerse(longVerse.Book, longVerse.Chapter, longVerse.Verses[0])
 	prev := longVerse.Verses[0]
@@ -137,13 +134,12 @@ func (list *VersesList) getRandomVerse() string {
 	return formatResult(result, bible.Books[longVerse.Book-1].ShortTitle, longVerse.Chapter, longVerse.Verses)
 }
 
-func getRandomVerseFromList(listId int) string {
-	for _, verseList := range versesLists {
-		if verseList.Id == listId {
-			return verseList.getRandomVerse()
-		}
+func getRandomVerseFromList(listId int, lang Language) string {
+	list, err := storage.GetVersesList(listId)
+	if err != nil || len(list.List) == 0 {
+		return ""
 	}
-	return ""
+	return list.getRandomVerse(bibleForLanguage(lang))
 }
 
 func formatResult(text string, book string, chapter int, verses []int) string {
@@ -24,7 +24,7 @@ func getAdminId() {
 }
 
 func broadcastMessageToAll(text string, entities []MessageEntity) error {
-	chats, err := dbGetAllChats()
+	chats, err := storage.GetAllChats()
 	if err != nil { return err }
 	for _, chatId := range chats {
 		if chatId == adminId { continue }
@@ -33,7 +33,7 @@ func broadcastMessageToAll(text string, entities []MessageEntity) error {
 			Text: text,
 			Entities: entities,
 		}
-		go sendMessage(message)
+		queueMessage(message)
 	}
 	return nil
 }
@@ -46,7 +46,7 @@ func sendErrorReport(err error, msg string) {
 		return
 	}
 	errorReportTimeoutOn = true
-	scheduler.NewJob(gocron.OneTimeJob(gocron.OneTimeJobStartDateTime(time.Now().Add(errorReportTimeout))), gocron.NewTask(func () {
+	scheduler.NewJob(gocron.OneTimeJob(gocron.OneTimeJobStartDateTime(scheduler.Now().Add(errorReportTimeout))), gocron.NewTask(func () {
 		errorReportTimeoutOn = false
 	}))
 	sendMessage(SendMessage{
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+type CalendarTradition string
+
+const (
+	CalendarOrthodox CalendarTradition = "orthodox"
+	CalendarWestern  CalendarTradition = "western"
+)
+
+type VerseSource string
+
+const (
+	VerseSourceRandom   VerseSource = "random"
+	VerseSourceCalendar VerseSource = "calendar"
+)
+
+// Feast is either fixed, on Month and Day, or moveable, EasterOffset days from Easter.
+// Fixed Orthodox feasts are given in the Julian calendar.
+type Feast struct {
+	Key          string
+	Month        time.Month
+	Day          int
+	Moveable     bool
+	EasterOffset int
+	Readings     []LongVerse
+}
+
+// Readings use the Synodal book order and numbering of bible.json, with psalms numbered as in the Septuagint.
+var (
+	readingNativity       = []LongVerse{{42, 2, []int{10, 11}}, {23, 9, []int{6}}, {43, 1, []int{14}}}
+	readingTheophany      = []LongVerse{{40, 3, []int{16, 17}}}
+	readingEpiphany       = []LongVerse{{40, 2, []int{10, 11}}, {23, 60, []int{3}}}
+	readingMeeting        = []LongVerse{{42, 2, []int{29, 30, 31, 32}}}
+	readingAnnunciation   = []LongVerse{{42, 1, []int{30, 31}}, {42, 1, []int{38}}}
+	readingPalmSunday     = []LongVerse{{43, 12, []int{13}}, {38, 9, []int{9}}}
+	readingGoodFriday     = []LongVerse{{23, 53, []int{5}}, {43, 3, []int{16}}, {43, 19, []int{30}}}
+	readingEaster         = []LongVerse{{40, 28, []int{6}}, {43, 11, []int{25, 26}}, {53, 15, []int{20}}}
+	readingAscension      = []LongVerse{{44, 1, []int{9}}, {44, 1, []int{11}}}
+	readingPentecost      = []LongVerse{{44, 2, []int{4}}, {43, 14, []int{26}}, {43, 7, []int{37, 38}}}
+	readingTransfiguraton = []LongVerse{{40, 17, []int{2}}, {40, 17, []int{5}}}
+	readingTheotokos      = []LongVerse{{42, 1, []int{28}}, {42, 1, []int{46, 47, 48}}, {42, 11, []int{28}}}
+	readingCross          = []LongVerse{{53, 1, []int{18}}, {55, 6, []int{14}}}
+	readingAshWednesday   = []LongVerse{{29, 2, []int{12, 13}}, {19, 50, []int{3, 4}}}
+)
+
+var orthodoxFeasts = []Feast{
+	{Key: "nativity", Month: time.December, Day: 25, Readings: readingNativity},
+	{Key: "theophany", Month: time.January, Day: 6, Readings: readingTheophany},
+	{Key: "meeting", Month: time.February, Day: 2, Readings: readingMeeting},
+	{Key: "annunciation", Month: time.March, Day: 25, Readings: readingAnnunciation},
+	{Key: "palm_sunday", Moveable: true, EasterOffset: -7, Readings: readingPalmSunday},
+	{Key: "pascha", Moveable: true, EasterOffset: 0, Readings: readingEaster},
+	{Key: "ascension", Moveable: true, EasterOffset: 39, Readings: readingAscension},
+	{Key: "trinity", Moveable: true, EasterOffset: 49, Readings: readingPentecost},
+	{Key: "transfiguration", Month: time.August, Day: 6, Readings: readingTransfiguraton},
+	{Key: "dormition", Month: time.August, Day: 15, Readings: readingTheotokos},
+	{Key: "nativity_theotokos", Month: time.September, Day: 8, Readings: readingTheotokos},
+	{Key: "exaltation", Month: time.September, Day: 14, Readings: readingCross},
+	{Key: "entry_theotokos", Month: time.November, Day: 21, Readings: readingTheotokos},
+}
+
+var westernFeasts = []Feast{
+	{Key: "epiphany", Month: time.January, Day: 6, Readings: readingEpiphany},
+	{Key: "presentation", Month: time.February, Day: 2, Readings: readingMeeting},
+	{Key: "annunciation", Month: time.March, Day: 25, Readings: readingAnnunciation},
+	{Key: "ash_wednesday", Moveable: true, EasterOffset: -46, Readings: readingAshWednesday},
+	{Key: "palm_sunday", Moveable: true, EasterOffset: -7, Readings: readingPalmSunday},
+	{Key: "good_friday", Moveable: true, EasterOffset: -2, Readings: readingGoodFriday},
+	{Key: "easter", Moveable: true, EasterOffset: 0, Readings: readingEaster},
+	{Key: "ascension", Moveable: true, EasterOffset: 39, Readings: readingAscension},
+	{Key: "pentecost", Moveable: true, EasterOffset: 49, Readings: readingPentecost},
+	{Key: "transfiguration", Month: time.August, Day: 6, Readings: readingTransfiguraton},
+	{Key: "christmas", Month: time.December, Day: 25, Readings: readingNativity},
+}
+
+var seasonReadings = map[string][]LongVerse{
+	"pascha": {{43, 11, []int{25, 26}}, {53, 15, []int{20}}, {52, 6, []int{9}}, {46, 1, []int{3}},
+		{40, 28, []int{6}}, {66, 1, []int{18}}},
+	"lent": {{29, 2, []int{12, 13}}, {19, 50, []int{3, 4}}, {40, 6, []int{16, 17, 18}}, {23, 58, []int{6}},
+		{48, 1, []int{9}}, {42, 18, []int{13}}},
+	"advent": {{23, 9, []int{6}}, {23, 7, []int{14}}, {33, 5, []int{2}}, {42, 1, []int{31, 32}},
+		{52, 13, []int{11, 12}}, {23, 40, []int{3}}},
+}
+
+func parseCalendarTradition(value string) (CalendarTradition, bool) {
+	switch CalendarTradition(value) {
+	case CalendarOrthodox, CalendarWestern:
+		return CalendarTradition(value), true
+	}
+	return "", false
+}
+
+// julianCalendarShift is the number of days the Julian calendar lags behind the Gregorian one in the year.
+func julianCalendarShift(year int) int {
+	return year/100 - year/400 - 2
+}
+
+// westernEaster returns Gregorian Easter, computed with the anonymous Gregorian algorithm.
+func westernEaster(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// orthodoxEaster returns Orthodox Pascha as a Gregorian date, computed with Meeus' Julian algorithm.
+func orthodoxEaster(year int) time.Time {
+	a := year % 4
+	b := year % 7
+	c := year % 19
+	d := (19*c + 15) % 30
+	e := (2*a + 4*b - d + 34) % 7
+	month := (d + e + 114) / 31
+	day := (d+e+114)%31 + 1
+	return time.Date(year, time.Month(month), day+julianCalendarShift(year), 0, 0, 0, 0, time.UTC)
+}
+
+func easter(tradition CalendarTradition, year int) time.Time {
+	if tradition == CalendarOrthodox {
+		return orthodoxEaster(year)
+	}
+	return westernEaster(year)
+}
+
+func calendarFeasts(tradition CalendarTradition) []Feast {
+	if tradition == CalendarOrthodox {
+		return orthodoxFeasts
+	}
+	return westernFeasts
+}
+
+// calendarDay returns the date as a UTC midnight together with its month and day in the tradition's calendar.
+func calendarDay(tradition CalendarTradition, date time.Time) (time.Time, time.Month, int) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	if tradition == CalendarOrthodox {
+		julian := day.AddDate(0, 0, -julianCalendarShift(day.Year()))
+		return day, julian.Month(), julian.Day()
+	}
+	return day, day.Month(), day.Day()
+}
+
+func daysFromEaster(tradition CalendarTradition, day time.Time) int {
+	return int(day.Sub(easter(tradition, day.Year())).Hours() / 24)
+}
+
+// feastOn returns the feast celebrated on the date, taken as a day in its own location.
+func feastOn(tradition CalendarTradition, date time.Time) (Feast, bool) {
+	day, month, dayOfMonth := calendarDay(tradition, date)
+	fromEaster := daysFromEaster(tradition, day)
+	for _, feast := range calendarFeasts(tradition) {
+		if feast.Moveable && feast.EasterOffset == fromEaster ||
+			!feast.Moveable && feast.Month == month && feast.Day == dayOfMonth {
+			return feast, true
+		}
+	}
+	return Feast{}, false
+}
+
+// nextFeast returns the first feast on the date or after it.
+func nextFeast(tradition CalendarTradition, date time.Time) (Feast, time.Time) {
+	for i := 0; i <= 366; i++ {
+		day := date.AddDate(0, 0, i)
+		if feast, ok := feastOn(tradition, day); ok {
+			return feast, day
+		}
+	}
+	return Feast{}, time.Time{}
+}
+
+// calendarSeason returns "pascha", "lent", "advent", or "" for ordinary days.
+func calendarSeason(tradition CalendarTradition, date time.Time) string {
+	day, month, dayOfMonth := calendarDay(tradition, date)
+	fromEaster := daysFromEaster(tradition, day)
+	lentStart := -48
+	if tradition == CalendarWestern {
+		lentStart = -46
+	}
+	switch {
+	case fromEaster > 0 && fromEaster < 49:
+		return "pascha"
+	case fromEaster >= lentStart && fromEaster < 0:
+		return "lent"
+	}
+	if tradition == CalendarOrthodox {
+		// The Nativity fast lasts from November 15 to December 24 in the Julian calendar.
+		if month == time.November && dayOfMonth >= 15 || month == time.December && dayOfMonth <= 24 {
+			return "advent"
+		}
+		return ""
+	}
+	// Advent starts on the Sunday between November 27 and December 3.
+	adventStart := time.Date(day.Year(), time.November, 27, 0, 0, 0, 0, time.UTC)
+	for adventStart.Weekday() != time.Sunday {
+		adventStart = adventStart.AddDate(0, 0, 1)
+	}
+	if !day.Before(adventStart) && day.Before(time.Date(day.Year(), time.December, 25, 0, 0, 0, 0, time.UTC)) {
+		return "advent"
+	}
+	return ""
+}
+
+// calendarReading returns a verse for the day: a feast reading, a reading of the season,
+// or a random verse on ordinary days.
+func calendarReading(tradition CalendarTradition, date time.Time, lang Language) string {
+	bible := bibleForLanguage(lang)
+	if feast, ok := feastOn(tradition, date); ok {
+		list := VersesList{List: feast.Readings}
+		return list.getRandomVerse(bible)
+	}
+	if readings, ok := seasonReadings[calendarSeason(tradition, date)]; ok {
+		list := VersesList{List: readings}
+		return list.getRandomVerse(bible)
+	}
+	return bible.getRandomVerse()
+}
+
+// getChatCalendarTradition returns the chat's tradition, Orthodox by default
+// for Russian and Ukrainian chats and Western for the others.
+func getChatCalendarTradition(chatId int64, lang Language) CalendarTradition {
+	tradition, err := storage.GetCalendarTradition(chatId)
+	if err == nil && tradition != "" {
+		return tradition
+	}
+	if lang == LanguageEnglish {
+		return CalendarWestern
+	}
+	return CalendarOrthodox
+}
+
+// scheduledVerse returns the verse to send on a schedule according to the chat's verse source.
+func scheduledVerse(chatId int64, lang Language) string {
+	source, err := storage.GetVerseSource(chatId)
+	if err == nil && source == VerseSourceCalendar {
+		return calendarReading(getChatCalendarTradition(chatId, lang), scheduler.Now().In(getChatLocation(chatId)), lang)
+	}
+	return bibleForLanguage(lang).getRandomVerse()
+}
+
+// getFeastMessage describes today's feast, or the nearest one, with one of its readings.
+func getFeastMessage(chatId int64, lang Language) string {
+	tradition := getChatCalendarTradition(chatId, lang)
+	now := scheduler.Now().In(getChatLocation(chatId))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	feast, day := nextFeast(tradition, today)
+	if day.IsZero() {
+		return tr(lang, "feast_not_found")
+	}
+	name := tr(lang, "feast_"+feast.Key)
+	list := VersesList{List: feast.Readings}
+	verse := list.getRandomVerse(bibleForLanguage(lang))
+	if day.Equal(today) {
+		return tr(lang, "feast_today", name, verse)
+	}
+	date := tr(lang, "feast_date", day.Day(), tr(lang, fmt.Sprintf("month_gen_%d", day.Month())))
+	days := int(day.Sub(today).Hours() / 24)
+	return tr(lang, "feast_next", name, date, trn(lang, "days_until", days), verse)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+const defaultCatchUpGracePeriod = 2 * time.Hour
+
+func getCatchUpGracePeriod() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("CATCH_UP_GRACE_MINUTES"))
+	if err != nil || minutes < 0 {
+		return defaultCatchUpGracePeriod
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+func cronFiredBetween(cron string, loc *time.Location, from time.Time, to time.Time) bool {
+	schedule, err := parseCron(cron)
+	if err != nil {
+		return false
+	}
+	next := schedule.Next(from.In(loc))
+	return !next.IsZero() && !next.After(to)
+}
+
+// catchUpMissedSends sends at most one verse for every schedule
+// that should have fired while the bot was down, if it is not too late.
+func catchUpMissedSends() error {
+	gracePeriod := getCatchUpGracePeriod()
+	if gracePeriod == 0 {
+		return nil
+	}
+	now := scheduler.Now()
+	earliest := now.Add(-gracePeriod)
+	chats, err := storage.GetAllChats()
+	if err != nil { return err }
+	for _, chatId := range chats {
+		timezone, err := storage.GetTimezone(chatId)
+		if err != nil { return err }
+		loc, err := time.LoadLocation(timezone)
+		if err != nil { loc = defaultLocation }
+		cronsLastSent, err := storage.GetCronsLastSent(chatId)
+		if err != nil { return err }
+		for cron, lastSent := range cronsLastSent {
+			from := earliest
+			if lastSent.After(from) {
+				from = lastSent
+			}
+			if cronFiredBetween(cron, loc, from, now) {
+				println("sending missed verse for cron", chatId, cron)
+				cronTask(chatId, cron)
+			}
+		}
+		randomTimes, err := storage.GetAllRandomTimes(chatId)
+		if err != nil { return err }
+		for _, rt := range randomTimes {
+			for _, send := range rt.NextSends {
+				if send.After(earliest) && send.Before(now) {
+					println("sending missed verse for random time", chatId, rt.Id)
+					randomVerseTask(chatId)
+					break
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -1,43 +1,100 @@
 package main
 
 import (
+	"database/sql"
 	"slices"
 	"strconv"
 	"time"
-
-	"github.com/google/uuid"
 )
 
 const defaultTimezone = "Europe/Moscow"
 
 var defaultLocation *time.Location
 
-var chatsCronJobsIds = make(map[int64]map[string]uuid.UUID)
-var chatsRandomTimeJobsIds = make(map[int64]map[int]map[string]uuid.UUID)
-
 type MessageStatus int
 
 const (
-	MessageStatusDefault     MessageStatus = 0
-	MessageStatusAddCron1    MessageStatus = 1
-	MessageStatusAddCron2    MessageStatus = 2
-	MessageStatusAddCron3    MessageStatus = 3
-	MessageStatusAddCron4    MessageStatus = 4
-	MessageStatusAddCronCron MessageStatus = 5
-	MessageStatusAddCron5    MessageStatus = 6
-	MessageStatusSetTimezone MessageStatus = 20
-	MessageStatusBroadcast   MessageStatus = 10000
+	MessageStatusDefault          MessageStatus = 0
+	MessageStatusAddCron1         MessageStatus = 1
+	MessageStatusAddCron2         MessageStatus = 2
+	MessageStatusAddCron3         MessageStatus = 3
+	MessageStatusAddCron4         MessageStatus = 4
+	MessageStatusAddCronCron      MessageStatus = 5
+	MessageStatusAddCron5         MessageStatus = 6
+	MessageStatusAddCron5Days     MessageStatus = 7
+	MessageStatusEditCron         MessageStatus = 8
+	MessageStatusEditRandomWindow MessageStatus = 9
+	MessageStatusEditRandomDays   MessageStatus = 10
+	MessageStatusConfirmCron      MessageStatus = 11
+	MessageStatusAddNatural       MessageStatus = 12
+	MessageStatusConfirmRandom    MessageStatus = 13
+	MessageStatusSetDates         MessageStatus = 14
+	MessageStatusAddSolarLocation MessageStatus = 15
+	MessageStatusAddSolar         MessageStatus = 16
+	MessageStatusSetTimezone      MessageStatus = 20
+	MessageStatusBroadcast        MessageStatus = 10000
 )
 
+// WeekDays is -1 for every day, otherwise a bit mask of time.Weekday values.
 type RandomTimeVerse struct {
 	Id        int
-	WeekDay   int
+	WeekDays  int
 	StartTime int
 	Duration  int
+	Count     int
 	NextSends []time.Time
+	Dates     DateBounds
+}
+
+// DateBounds limits a schedule to the days from StartsOn to EndsOn, both included.
+// A null bound leaves that side open.
+type DateBounds struct {
+	StartsOn sql.NullTime
+	EndsOn   sql.NullTime
+}
+
+type SolarEvent int
+
+const (
+	SolarEventSunrise SolarEvent = 0
+	SolarEventSunset  SolarEvent = 1
+)
+
+// SolarVerse is a schedule relative to sunrise or sunset. Offset is in minutes, negative before the event.
+type SolarVerse struct {
+	Id     int
+	Event  SolarEvent
+	Offset int
+}
+
+// Reminder is a one-time verse send.
+type Reminder struct {
+	Id     int
+	SendAt time.Time
+}
+
+// DueCron is a cron claimed by the dispatcher. NextFireAt is null until it is computed.
+type DueCron struct {
+	ChatId     int64
+	Cron       string
+	LastSent   time.Time
+	NextFireAt sql.NullTime
+}
+
+type DueSolar struct {
+	ChatId     int64
+	Solar      SolarVerse
+	NextFireAt sql.NullTime
 }
 
-const randomVerseTextMessage = "Следующий случайный стих"
+// DueSend is a one-time send taken by the dispatcher: a random time send or a reminder.
+type DueSend struct {
+	ChatId int64
+	At     time.Time
+}
+
+const maxRandomTimeCount = 10
+const minRandomSendsSpacing = 30
 
 type Stats struct {
 	Date  string
@@ -49,6 +106,13 @@ type StatsListChats struct {
 	Chats map[string][]int64
 }
 
+const (
+	everyDayMask = -1
+	workDaysMask = 1<<time.Monday | 1<<time.Tuesday | 1<<time.Wednesday | 1<<time.Thursday | 1<<time.Friday
+	weekendsMask = 1<<time.Saturday | 1<<time.Sunday
+	allDaysMask  = workDaysMask | weekendsMask
+)
+
 var statsLocation *time.Location
 
 type PeriodStats struct {
@@ -92,15 +156,15 @@ func getStatsMessageText(startDate string, endDate string, groupBy string) (stri
 	// if err != nil {
 	// 	return "", err
 	// }
-	stats, err := dbGetStatsInRange(startDate, endDate)
+	stats, err := storage.GetStatsInRange(startDate, endDate)
 	if err != nil {
 		return "", err
 	}
-	statsListChats, err := dbGetStatsListChatsInRange(startDate, endDate)
+	statsListChats, err := storage.GetStatsListChatsInRange(startDate, endDate)
 	if err != nil {
 		return "", err
 	}
-	chats, err := dbGetAllChats()
+	chats, err := storage.GetAllChats()
 	if err != nil {
 		return "", err
 	}
@@ -171,24 +235,39 @@ func getStatsMessageText(startDate string, endDate string, groupBy string) (stri
 	return text, nil
 }
 
-func sendErrorMessage(chatId int64) {
-	go sendMessage(SendMessage{
+func sendErrorMessage(chatId int64, lang Language) {
+	sendMessage(SendMessage{
 		ChatId: chatId,
-		Text:   "Внутренняя ошибка бота. Уже работаем над исправлением.",
+		Text:   tr(lang, "internal_error"),
 	})
 }
 
-func getStartMessage(chatId int64) SendMessage {
+func getStartMessage(chatId int64, lang Language) SendMessage {
 	return SendMessage{
 		ChatId: chatId,
-		Text: escapingSymbols("Добро пожаловать! Я - бот для отправки случайных стихов из Библии. Например:\n\n"+getRandomVerseFromList(2)+
-			"\n\nЧтобы получить случайный стих, используйте команду /random.\n\n"+
-			"Можете настроить расписания получения случайных стихов с помощью команд /getregular, /addregular, /removeregular, /clearregular.\n\n") +
-			"По умолчанию установлен часовой пояс `Europe/Moscow` \\(UTC\\+3\\)\\. " +
-			"Можете отправить геопозицию для определения вашего часового пояса, ввести название вручную, выбрать разницу с UTC, " +
-			"или использовать /cancel для сохранения `Europe/Moscow`\\.\n\n" +
-			"Вы можете использовать команды /gettimezone и /settimezone для просмотра и смены часового пояса\\.",
-		ReplyMarkup: chooseTimezoneKeyboard,
+		Text: escapingSymbols(tr(lang, "start_greeting")+getRandomVerseFromList(2, lang)+tr(lang, "start_commands")) +
+			tr(lang, "start_timezone"),
+		ReplyMarkup: chooseTimezoneKeyboard(lang),
 		ParseMode:   "MarkdownV2",
 	}
 }
+
+var languageKeyboard = InlineKeyboardMarkup{[][]InlineKeyboardButton{{
+	{languageNames[LanguageRussian], "language:" + string(LanguageRussian)},
+	{languageNames[LanguageUkrainian], "language:" + string(LanguageUkrainian)},
+	{languageNames[LanguageEnglish], "language:" + string(LanguageEnglish)},
+}}}
+
+func calendarKeyboard(lang Language) InlineKeyboardMarkup {
+	return InlineKeyboardMarkup{[][]InlineKeyboardButton{{
+		{tr(lang, "calendar_orthodox"), "calendar:" + string(CalendarOrthodox)},
+		{tr(lang, "calendar_western"), "calendar:" + string(CalendarWestern)},
+	}}}
+}
+
+func verseSourceKeyboard(lang Language) InlineKeyboardMarkup {
+	return InlineKeyboardMarkup{[][]InlineKeyboardButton{
+		{{tr(lang, "verse_source_random"), "source:" + string(VerseSourceRandom)}},
+		{{tr(lang, "verse_source_calendar"), "source:" + string(VerseSourceCalendar)}},
+	}}
+}
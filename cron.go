@@ -1,6 +1,7 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,48 +15,8 @@ import (
 	"time"
 
 	"github.com/go-co-op/gocron/v2"
-	"github.com/google/uuid"
 )
 
-var validCronMinNumbers = []int{0, 0, 1, 1, 0}
-var validCronMaxNumbers = []int{59, 23, 31, 12, 6}
-
-func checkValidCron(cron string) bool {
-	parts := strings.Split(cron, " ")
-	for ind, part := range parts {
-		spl1 := strings.Split(part, "/")
-		if len(spl1) > 2 {
-			return false
-		}
-		if len(spl1) == 2 {
-			i, err := strconv.Atoi(spl1[1])
-			if err != nil || i <= 0 || i > validCronMaxNumbers[ind] {
-				return false
-			}
-		}
-		spl2 := strings.Split(spl1[0], ",")
-		for _, ran := range spl2 {
-			if ran == "*" {
-				continue
-			}
-			spl3 := strings.Split(ran, "-")
-			if len(spl3) == 1 {
-				i, err := strconv.Atoi(spl3[0])
-				if err != nil || i < validCronMinNumbers[ind] || i > validCronMaxNumbers[ind] {
-					return false
-				}
-			} else {
-				i1, err1 := strconv.Atoi(spl3[0])
-				i2, err2 := strconv.Atoi(spl3[0])
-				if err1 != nil || err2 != nil || i1 < validCronMinNumbers[ind] || i1 > i2 || i2 > validCronMaxNumbers[ind] {
-					return false
-				}
-			}
-		}
-	}
-	return true
-}
-
 func timeToString(time int) string {
 	result := ""
 	hours := time / 60
@@ -178,64 +139,82 @@ func parseListWeekDayTimesToCron(timeInput string) ([]string, error) {
 	return list, nil
 }
 
-var weekDaysNames = []string{
-	"Каждое воскресенье",
-	"Каждый понедельник",
-	"Каждый вторник",
-	"Каждую среду",
-	"Каждый четверг",
-	"Каждую пятницу",
-	"Каждую субботу",
-	"Каждое воскресенье"}
-
-func cronToString(cron string) string {
-	parts := strings.Split(cron, " ")
-	if strings.Contains(parts[0], ",") || strings.Contains(parts[0], "-") || strings.Contains(parts[0], "/") || strings.Contains(parts[0], "*") {
-		return cron
-	}
-	if strings.Contains(parts[1], ",") || strings.Contains(parts[1], "-") || strings.Contains(parts[1], "/") || strings.Contains(parts[1], "*") {
-		return cron
-	}
-	if parts[0] == "*" && parts[1] == "*" && parts[2] == "*" && parts[3] == "*" && parts[4] == "*" {
-		return "Каждую минуту"
-	}
-	if len(parts[0]) == 1 {
-		parts[0] = "0" + parts[0]
+func cronToString(cron string, lang Language) string {
+	schedule, err := parseCron(cron)
+	if err != nil { return cron }
+	if description, ok := describeCron(schedule, lang); ok {
+		return description
 	}
-	if parts[1] == "*" && parts[2] == "*" && parts[3] == "*" && parts[4] == "*" {
-		return fmt.Sprintf("Каждый час в %s минут", parts[0])
+	return schedule.String()
+}
+
+func weekDaysMaskContains(mask int, weekDay time.Weekday) bool {
+	return mask == everyDayMask || mask&(1<<weekDay) != 0
+}
+
+func parseWeekDaysList(input string) (int, error) {
+	mask := 0
+	for _, part := range strings.Split(input, ",") {
+		day, err := strconv.Atoi(strings.Trim(part, " "))
+		if err != nil || day < 0 || day > 7 {
+			return 0, io.ErrShortWrite
+		}
+		mask |= 1 << (day % 7)
 	}
-	if len(parts[1]) == 1 {
-		parts[1] = "0" + parts[1]
+	if mask == allDaysMask {
+		return everyDayMask, nil
 	}
-	if parts[2] == "*" && parts[3] == "*" && parts[4] == "*" {
-		return fmt.Sprintf("Каждый день в %s:%s", parts[1], parts[0])
+	return mask, nil
+}
+
+func weekDaysToString(mask int, lang Language) string {
+	switch mask {
+	case everyDayMask, allDaysMask:
+		return tr(lang, "days_every_day")
+	case workDaysMask:
+		return tr(lang, "days_work_days")
+	case weekendsMask:
+		return tr(lang, "days_weekends")
 	}
-	weekDay, err := strconv.Atoi(parts[4])
-	if err != nil { return cron }
-	if parts[2] == "*" && parts[3] == "*" {
-		return fmt.Sprintf("%s в %s:%s", weekDaysNames[weekDay], parts[1], parts[0])
+	names := []string{}
+	for day := time.Monday; day <= time.Saturday+1; day++ {
+		if weekDaysMaskContains(mask, day%7) {
+			names = append(names, tr(lang, fmt.Sprintf("weekday_short_%d", day%7)))
+		}
 	}
-	return cron
+	return strings.Join(names, ", ")
 }
 
-func randomTimeToString(randomTime RandomTimeVerse) string {
-	endTime := randomTime.StartTime + randomTime.Duration
-	return "Каждый день в случайное время с " + timeToString(randomTime.StartTime) + " до " + timeToString(endTime)
+func randomTimeToString(randomTime RandomTimeVerse, lang Language) string {
+	endTime := (randomTime.StartTime + randomTime.Duration) % (24 * 60)
+	if randomTime.Count > 1 {
+		return tr(lang, "random_time_count", weekDaysToString(randomTime.WeekDays, lang), trn(lang, "times_count", randomTime.Count),
+			timeToString(randomTime.StartTime), timeToString(endTime))
+	}
+	return tr(lang, "random_time", weekDaysToString(randomTime.WeekDays, lang), timeToString(randomTime.StartTime), timeToString(endTime))
 }
 
-func randomTimeToShortString(randomTime RandomTimeVerse) string {
-	endTime := randomTime.StartTime + randomTime.Duration
-	return "Случайно с " + timeToString(randomTime.StartTime) + " до " + timeToString(endTime)
+func randomTimeToShortString(randomTime RandomTimeVerse, lang Language) string {
+	endTime := (randomTime.StartTime + randomTime.Duration) % (24 * 60)
+	text := tr(lang, "random_time_short", timeToString(randomTime.StartTime), timeToString(endTime))
+	if randomTime.Count > 1 {
+		text = tr(lang, "random_time_short_count", trn(lang, "times_count", randomTime.Count),
+			timeToString(randomTime.StartTime), timeToString(endTime))
+	}
+	if randomTime.WeekDays != everyDayMask {
+		text = weekDaysToString(randomTime.WeekDays, lang) + ": " + text
+	}
+	return text
 }
 
 var errExistingCron = errors.New("cron already exists")
+var errExistingRandomTime = errors.New("random time already exists")
+var errRandomTimeNotFound = errors.New("random time not found")
+var errCronNotFound = errors.New("cron not found")
+var errRandomWindowTooShort = errors.New("random time window too short for the count")
 
 func addCronsForChat(crons []string, chatId int64, onlyJob bool) error {
-	if chatsCronJobsIds[chatId] == nil {
-		chatsCronJobsIds[chatId] = make(map[string]uuid.UUID)
-	}
-	exCrons, err := dbGetAllCrons(chatId)
+	exCrons, err := storage.GetAllCrons(chatId)
 	if err != nil { return err }
 	if !onlyJob {
 		for _, cron := range crons {
@@ -246,48 +225,174 @@ func addCronsForChat(crons []string, chatId int64, onlyJob bool) error {
 					return errExistingCron
 				}
 			}
-			err := dbAddCron(chatId, cron)
+			err := storage.AddCron(chatId, cron)
 			if err != nil {
 				return err
 			}
 		}
 	}
-	timezone, err := dbGetTimezone(chatId)
+	timezone, err := storage.GetTimezone(chatId)
 	if err != nil { return err }
 	for _, cron := range crons {
-		job, err := scheduler.NewJob(gocron.CronJob(fmt.Sprintf("TZ=%s %s", timezone, cron), false),
-			gocron.NewTask(randomVerseTask, chatId))
+		err := jobRegistry.add(cronJobKey(chatId, cron), gocron.CronJob(fmt.Sprintf("TZ=%s %s", timezone, cron), false),
+			gocron.NewTask(cronTask, chatId, cron))
 		if err != nil {
 			println(err.Error())
-			continue
 		}
-		chatsCronJobsIds[chatId][cron] = job.ID()
+	}
+	return planCronGapFires(chatId, crons)
+}
+
+// cronGapFires returns the fires of the cron on the day whose wall time does not exist
+// because clocks go forward, moved by the length of the gap as time.Date does.
+func cronGapFires(schedule CronSchedule, day time.Time, loc *time.Location) []time.Time {
+	result := []time.Time{}
+	dayStart := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+	if !schedule.has(cronMonth, int(dayStart.Month())) || !schedule.dayMatches(dayStart) {
+		return result
+	}
+	for _, hour := range schedule.values(cronHour) {
+		for _, minute := range schedule.values(cronMinute) {
+			fire := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc)
+			if fire.Hour() != hour || fire.Minute() != minute {
+				result = append(result, fire)
+			}
+		}
+	}
+	return result
+}
+
+// planCronGapFires adds one-time jobs for the fires of today and tomorrow that gocron
+// skips because their wall time does not exist on a day clocks go forward.
+func planCronGapFires(chatId int64, crons []string) error {
+	loc := getChatLocation(chatId)
+	now := scheduler.Now().In(loc)
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day() + 1, 0, 0, 0, 0, loc)
+	for _, cron := range crons {
+		schedule, err := parseCron(cron)
+		if err != nil { continue }
+		for _, day := range []time.Time{now, tomorrow} {
+			for _, fire := range cronGapFires(schedule, day, loc) {
+				if !fire.After(now) { continue }
+				key := JobKey{chatId, JobKindCron, cron, fire.UTC().Format(time.RFC3339)}
+				err = jobRegistry.add(key, gocron.OneTimeJob(gocron.OneTimeJobStartDateTime(fire)),
+					gocron.NewTask(cronGapTask, chatId, cron, key))
+				if err != nil { return err }
+			}
+		}
+	}
+	return nil
+}
+
+func setDailyCronGapFires() error {
+	chats, err := storage.GetAllChats()
+	if err != nil { return err }
+	for _, chatId := range chats {
+		crons, err := storage.GetAllCrons(chatId)
+		if err != nil { return err }
+		err = planCronGapFires(chatId, crons)
+		if err != nil { return err }
 	}
 	return nil
 }
 
 func randomVerseTask(chatId int64) {
+	if isChatPaused(chatId, scheduler.Now()) {
+		return
+	}
+	lang, _ := storage.GetLanguage(chatId)
 	message := SendMessage{
 		ChatId: chatId,
-		Text:   bible.getRandomVerse(),
+		Text:   scheduledVerse(chatId, lang),
 	}
-	dbStatPlusOne(time.Now().In(statsLocation).Format(time.DateOnly), "scheduled_sent")
-	go sendMessage(message)
+	storage.StatPlusOne(scheduler.Now().In(statsLocation).Format(time.DateOnly), "scheduled_sent")
+	queueMessage(message)
 }
 
-func randomTimeTask(chatId int64, randomTime RandomTimeVerse, date string) {
-	randomVerseTask(chatId)
-	delete(chatsRandomTimeJobsIds[chatId][randomTime.Id], date)
+// randomSendOffsets picks count random minutes in (0, duration] that are at least
+// minRandomSendsSpacing minutes apart from each other. Windows saved before the spacing
+// was checked may be too short, then only as many sends as fit are picked.
+func randomSendOffsets(duration int, count int) []int {
+	if count < 1 {
+		count = 1
+	}
+	if (count-1)*minRandomSendsSpacing >= duration {
+		count = (duration-1)/minRandomSendsSpacing + 1
+	}
+	free := duration - (count-1)*minRandomSendsSpacing
+	offsets := []int{}
+	for i := 0; i < count; i++ {
+		offsets = append(offsets, rand.Intn(free)+1)
+	}
+	slices.Sort(offsets)
+	for i := range offsets {
+		offsets[i] += i * minRandomSendsSpacing
+	}
+	return offsets
 }
 
-func addRandomTimeForDay(day time.Time, randomTime RandomTimeVerse, chatId int64) error {
-	if chatsRandomTimeJobsIds[chatId] == nil {
-		chatsRandomTimeJobsIds[chatId] = make(map[int]map[string]uuid.UUID)
+func parseRandomTimeWindow(input string) (int, int, int, error) {
+	spl := strings.Split(strings.Trim(input, " "), ",")
+	if len(spl) != 2 && len(spl) != 3 {
+		return 0, 0, 0, io.ErrShortWrite
+	}
+	startTime, err := parseTime(spl[0])
+	if err != nil { return 0, 0, 0, err }
+	endTime, err := parseTime(spl[1])
+	if err != nil { return 0, 0, 0, err }
+	if startTime == endTime {
+		return 0, 0, 0, io.ErrShortWrite
 	}
-	if chatsRandomTimeJobsIds[chatId][randomTime.Id] == nil {
-		chatsRandomTimeJobsIds[chatId][randomTime.Id] = make(map[string]uuid.UUID)
+	count := 1
+	if len(spl) == 3 {
+		count, err = strconv.Atoi(strings.Trim(spl[2], " "))
+		if err != nil || count < 1 || count > maxRandomTimeCount {
+			return 0, 0, 0, io.ErrShortWrite
+		}
 	}
-	timezone, err := dbGetTimezone(chatId)
+	duration := (endTime - startTime + 24*60) % (24 * 60)
+	if !randomWindowFits(duration, count) {
+		return 0, 0, 0, errRandomWindowTooShort
+	}
+	return startTime, endTime, count, nil
+}
+
+// randomWindowFits tells whether count sends fit in the window keeping them
+// minRandomSendsSpacing minutes apart.
+func randomWindowFits(duration int, count int) bool {
+	return (count-1)*minRandomSendsSpacing < duration
+}
+
+func cronTask(chatId int64, cron string) {
+	now := scheduler.Now()
+	if !cronActiveAt(chatId, cron, now) {
+		return
+	}
+	// When clocks go back gocron fires again at the repeated wall time.
+	lastSent, err := storage.GetCronsLastSent(chatId)
+	if err == nil {
+		loc := getChatLocation(chatId)
+		if lastSent[cron].In(loc).Format("2006-01-02 15:04") == now.In(loc).Format("2006-01-02 15:04") {
+			return
+		}
+	}
+	randomVerseTask(chatId)
+	storage.UpdateCronLastSent(chatId, cron, now)
+}
+
+func cronGapTask(chatId int64, cron string, key JobKey) {
+	cronTask(chatId, cron)
+	jobRegistry.remove(key)
+}
+
+func randomTimeTask(chatId int64, randomTime RandomTimeVerse, send time.Time) {
+	randomVerseTask(chatId)
+	storage.RemoveNextSend(randomTime.Id, send)
+	jobRegistry.remove(sendJobKey(chatId, JobKindRandomTime, randomTime.Id, send))
+}
+
+func addRandomTimeForDay(day time.Time, randomTime RandomTimeVerse, chatId int64) error {
+	timezone, err := storage.GetTimezone(chatId)
 	if err != nil {
 		return err
 	}
@@ -296,37 +401,44 @@ func addRandomTimeForDay(day time.Time, randomTime RandomTimeVerse, chatId int64
 		loc = defaultLocation
 	}
 	dayStartTime := time.Date(day.Year(), day.Month(), day.Day(), randomTime.StartTime / 60, randomTime.StartTime % 60, 0, 0, loc)
-	if dayStartTime.Before(time.Now()) {
+	if dayStartTime.Before(scheduler.Now()) {
+		return nil
+	}
+	if !weekDaysMaskContains(randomTime.WeekDays, dayStartTime.Weekday()) {
+		return nil
+	}
+	if !randomTime.Dates.contains(dayStartTime, loc) {
+		return nil
+	}
+	if isChatPaused(chatId, dayStartTime.Add(time.Duration(randomTime.Duration)*time.Minute)) {
 		return nil
 	}
 	if len(randomTime.NextSends) > 0 && randomTime.NextSends[len(randomTime.NextSends)-1].After(dayStartTime) {
 		return nil
 	}
-	duration := rand.Intn(randomTime.Duration) + 1
-	newTime := dayStartTime.Add(time.Duration(duration) * time.Minute)
-	dbAddNextSend(randomTime.Id, newTime)
-	job, err := scheduler.NewJob(gocron.OneTimeJob(gocron.OneTimeJobStartDateTime(newTime)),
-		gocron.NewTask(func () {
-			randomTimeTask(chatId, randomTime, dayStartTime.Format(time.DateOnly))
-		}))
-	if err != nil {
-		sendErrorReport(err, "error adding random time for day job")
-		println(err.Error())
-	} else {
-		chatsRandomTimeJobsIds[chatId][randomTime.Id][dayStartTime.Format(time.DateOnly)] = job.ID()
+	for _, offset := range randomSendOffsets(randomTime.Duration, randomTime.Count) {
+		newTime := dayStartTime.Add(time.Duration(offset) * time.Minute)
+		storage.AddNextSend(randomTime.Id, newTime)
+		err := jobRegistry.add(sendJobKey(chatId, JobKindRandomTime, randomTime.Id, newTime),
+			gocron.OneTimeJob(gocron.OneTimeJobStartDateTime(newTime)),
+			gocron.NewTask(randomTimeTask, chatId, randomTime, newTime))
+		if err != nil {
+			sendErrorReport(err, "error adding random time for day job")
+			println(err.Error())
+		}
 	}
 	return nil
 }
 
 func setDailyRandomTimeTasks() error {
-	now := time.Now().In(defaultLocation)
+	now := scheduler.Now().In(defaultLocation)
 	tomorrow := time.Date(now.Year(), now.Month(), now.Day() + 1, 0, 0, 0, 0, defaultLocation)
-	chats, err := dbGetAllChats()
+	chats, err := storage.GetAllChats()
 	if err != nil {
 		return err
 	}
 	for _, chatId := range chats {
-		randomTimes, err := dbGetAllRandomTimes(chatId)
+		randomTimes, err := storage.GetAllRandomTimes(chatId)
 		if err != nil {
 			return err
 		}
@@ -339,34 +451,36 @@ func setDailyRandomTimeTasks() error {
 }
 
 func createRandomTimeJobsAfterRestart() error {
-	chats, err := dbGetAllChats()
+	chats, err := storage.GetAllChats()
 	if err != nil {
 		return err
 	}
 	for _, chatId := range chats {
-		if chatsRandomTimeJobsIds[chatId] == nil {
-			chatsRandomTimeJobsIds[chatId] = make(map[int]map[string]uuid.UUID)
-		}
-		randomTimes, err := dbGetAllRandomTimes(chatId)
+		err = createRandomTimeJobsForChat(chatId)
 		if err != nil {
 			return err
 		}
-		for _, rt := range randomTimes {
-			if chatsRandomTimeJobsIds[chatId][rt.Id] == nil {
-				chatsRandomTimeJobsIds[chatId][rt.Id] = make(map[string]uuid.UUID)
+	}
+	return nil
+}
+
+func createRandomTimeJobsForChat(chatId int64) error {
+	randomTimes, err := storage.GetAllRandomTimes(chatId)
+	if err != nil {
+		return err
+	}
+	for _, rt := range randomTimes {
+		for _, send := range rt.NextSends {
+			if !send.After(scheduler.Now()) {
+				continue
 			}
-			for _, send := range rt.NextSends {
-				job, err := scheduler.NewJob(gocron.OneTimeJob(gocron.OneTimeJobStartDateTime(send)),
-					gocron.NewTask(func () {
-						randomTimeTask(chatId, rt, send.Format(time.DateOnly))
-					}))
-				if err != nil {
-					sendErrorReport(err, "error creating random time job")
-					println("error creating random time job", err.Error())
-					return err
-				} else {
-					chatsRandomTimeJobsIds[chatId][rt.Id][send.Format(time.DateOnly)] = job.ID()
-				}
+			err := jobRegistry.add(sendJobKey(chatId, JobKindRandomTime, rt.Id, send),
+				gocron.OneTimeJob(gocron.OneTimeJobStartDateTime(send)),
+				gocron.NewTask(randomTimeTask, chatId, rt, send))
+			if err != nil {
+				sendErrorReport(err, "error creating random time job")
+				println("error creating random time job", err.Error())
+				return err
 			}
 		}
 	}
@@ -374,10 +488,10 @@ func createRandomTimeJobsAfterRestart() error {
 }
 
 func setCronJobs() error {
-	chats, err := dbGetAllChats()
+	chats, err := storage.GetAllChats()
 	if err != nil { return err }
 	for _, chatId := range chats {
-		crons, err := dbGetAllCrons(chatId)
+		crons, err := storage.GetAllCrons(chatId)
 		if err != nil { return err }
 		err = addCronsForChat(crons, chatId, true)
 		if err != nil { return err }
@@ -385,13 +499,25 @@ func setCronJobs() error {
 	return nil
 }
 
-func addRandomTimeRegular(chatId int64, startTime int, endTime int) error {
-	randomTime := RandomTimeVerse{-1, -1, startTime, endTime - startTime, []time.Time{}}
-	id, err := dbAddRandomTime(chatId, randomTime)
+func addRandomTimeRegular(chatId int64, startTime int, endTime int, weekDays int, count int) error {
+	duration := (endTime - startTime + 24*60) % (24 * 60)
+	randomTime := RandomTimeVerse{-1, weekDays, startTime, duration, count, []time.Time{}, DateBounds{}}
+	exRandomTimes, err := storage.GetAllRandomTimes(chatId)
+	if err != nil { return err }
+	for _, rt := range exRandomTimes {
+		if rt.WeekDays == randomTime.WeekDays && rt.StartTime == randomTime.StartTime && rt.Duration == randomTime.Duration {
+			return errExistingRandomTime
+		}
+	}
+	id, err := storage.AddRandomTime(chatId, randomTime)
 	if err != nil { return err }
 	randomTime.Id = id
-	now := time.Now()
-	timezone, err := dbGetTimezone(chatId)
+	return planRandomTime(chatId, randomTime)
+}
+
+func planRandomTime(chatId int64, randomTime RandomTimeVerse) error {
+	now := scheduler.Now()
+	timezone, err := storage.GetTimezone(chatId)
 	if err != nil { return err }
 	loc, err := time.LoadLocation(timezone)
 	if err != nil { loc = defaultLocation }
@@ -403,63 +529,163 @@ func addRandomTimeRegular(chatId int64, startTime int, endTime int) error {
 	return nil
 }
 
-func removeRandomTimeRegular(chatId int64, randomId int) error {
-	randomTime, err := dbGetRandomTimeById(randomId)
-	if err != nil { return err }
-	for _, send := range randomTime.NextSends {
-		scheduler.RemoveJob(chatsRandomTimeJobsIds[chatId][randomId][send.String()[:10]])
+func parseSingleScheduleToCron(input string) (string, error) {
+	if cron, err := parseTimeToCron(input); err == nil {
+		return cron, nil
 	}
-	err = dbRemoveRandomTime(chatId, randomId)
-	return err
+	if cron, err := parseWeekDayTimeToCron(input); err == nil {
+		return cron, nil
+	}
+	return normalizeCron(input)
 }
 
-func clearCronsForChat(chatId int64, onlyJobs bool) error {
-	idsList := chatsCronJobsIds[chatId]
-	for _, jobId := range idsList {
-		scheduler.RemoveJob(jobId)
+func replaceCronForChat(chatId int64, oldCron string, newCron string) error {
+	exCrons, err := storage.GetAllCrons(chatId)
+	if err != nil { return err }
+	if !slices.Contains(exCrons, oldCron) {
+		return errCronNotFound
+	}
+	if slices.Contains(exCrons, newCron) {
+		return errExistingCron
 	}
-	chatsCronJobsIds[chatId] = make(map[string]uuid.UUID)
-	if !onlyJobs {
-		err := dbRemoveAllCronsForChat(chatId)
+	timezone, err := storage.GetTimezone(chatId)
+	if err != nil { return err }
+	err = storage.ReplaceCron(chatId, oldCron, newCron)
+	if errors.Is(err, sql.ErrNoRows) { return errCronNotFound }
+	if err != nil { return err }
+	definition := gocron.CronJob(fmt.Sprintf("TZ=%s %s", timezone, newCron), false)
+	task := gocron.NewTask(cronTask, chatId, newCron)
+	err = jobRegistry.replace(cronJobKey(chatId, oldCron), cronJobKey(chatId, newCron), definition, task)
+	if err != nil {
+		sendErrorReport(err, "error replacing cron job")
 		return err
 	}
+	jobRegistry.removeSchedule(chatId, JobKindCron, oldCron)
+	return planCronGapFires(chatId, []string{newCron})
+}
+
+func planAllRandomTimesForChat(chatId int64) error {
+	randomTimes, err := storage.GetAllRandomTimes(chatId)
+	if err != nil { return err }
+	for _, rt := range randomTimes {
+		err = planRandomTime(chatId, rt)
+		if err != nil { return err }
+	}
 	return nil
 }
 
-func clearRandomTimesForChat(chatId int64) error {
-	for _, chatMap := range chatsRandomTimeJobsIds {
-		for _, rtMap := range chatMap {
-			for _, jobId := range rtMap {
-				scheduler.RemoveJob(jobId)
-			}
+func getChatLocation(chatId int64) *time.Location {
+	timezone, err := storage.GetTimezone(chatId)
+	if err != nil {
+		return defaultLocation
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return defaultLocation
+	}
+	return loc
+}
+
+func getRandomTimeForChat(chatId int64, randomTimeId int) (RandomTimeVerse, error) {
+	randomTimes, err := storage.GetAllRandomTimes(chatId)
+	if err != nil { return RandomTimeVerse{}, err }
+	for _, rt := range randomTimes {
+		if rt.Id == randomTimeId {
+			return rt, nil
 		}
 	}
-	return dbRemoveAllRandomTimesForChat(chatId)
+	return RandomTimeVerse{}, errRandomTimeNotFound
 }
 
-func recreateJobsForChat(chatId int64) error {
-	randomTimes, err := dbGetAllRandomTimes(chatId)
+func updateRandomTimeRegular(chatId int64, randomTime RandomTimeVerse) error {
+	exRandomTimes, err := storage.GetAllRandomTimes(chatId)
 	if err != nil { return err }
-	crons, err := dbGetAllCrons(chatId)
+	for _, rt := range exRandomTimes {
+		if rt.Id != randomTime.Id && rt.WeekDays == randomTime.WeekDays && rt.StartTime == randomTime.StartTime && rt.Duration == randomTime.Duration {
+			return errExistingRandomTime
+		}
+	}
+	_, err = getRandomTimeForChat(chatId, randomTime.Id)
 	if err != nil { return err }
-	err = clearCronsForChat(chatId, true)
+	err = storage.UpdateRandomTime(chatId, randomTime)
 	if err != nil { return err }
-	err = addCronsForChat(crons, chatId, true)
+	jobRegistry.removeSchedule(chatId, JobKindRandomTime, strconv.Itoa(randomTime.Id))
+	randomTime.NextSends = []time.Time{}
+	return planRandomTime(chatId, randomTime)
+}
+
+func removeRandomTimeRegular(chatId int64, randomId int) error {
+	_, err := storage.GetRandomTimeById(randomId)
 	if err != nil { return err }
-	err = clearRandomTimesForChat(chatId)
+	err = storage.RemoveRandomTime(chatId, randomId)
 	if err != nil { return err }
-	for _, rt := range randomTimes {
-		err = addRandomTimeRegular(chatId, rt.StartTime, rt.StartTime + rt.Duration)
-		if err != nil { return err }
+	jobRegistry.removeSchedule(chatId, JobKindRandomTime, strconv.Itoa(randomId))
+	return nil
+}
+
+func clearSchedulesForChat(chatId int64) error {
+	err := storage.RemoveAllSchedulesForChat(chatId)
+	if err != nil { return err }
+	for _, kind := range scheduleJobKinds {
+		jobRegistry.removeChat(chatId, kind)
 	}
 	return nil
 }
 
+// reloadJobsForChat recreates all jobs of the chat from the database.
+func reloadJobsForChat(chatId int64) error {
+	for _, kind := range scheduleJobKinds {
+		jobRegistry.removeChat(chatId, kind)
+	}
+	crons, err := storage.GetAllCrons(chatId)
+	if err != nil { return err }
+	err = addCronsForChat(crons, chatId, true)
+	if err != nil { return err }
+	err = createRandomTimeJobsForChat(chatId)
+	if err != nil { return err }
+	err = planAllRandomTimesForChat(chatId)
+	if err != nil { return err }
+	reminders, err := storage.GetAllReminders(chatId)
+	if err != nil { return err }
+	for _, reminder := range reminders {
+		if reminder.SendAt.After(scheduler.Now()) {
+			err = scheduleReminderJob(chatId, reminder)
+			if err != nil { return err }
+		}
+	}
+	return planSolarSchedules(chatId)
+}
+
+// changeChatTimezone stores the timezone and recreates the jobs of the chat for it.
+// If the jobs cannot be recreated, the old timezone and message status are stored back
+// and the jobs are recreated for them, so the chat is not left without its schedules.
+func changeChatTimezone(chatId int64, timezone string) error {
+	oldTimezone, err := storage.GetTimezone(chatId)
+	if err != nil { return err }
+	oldStatus, err := storage.GetMessageStatus(chatId)
+	if err != nil { return err }
+	err = storage.UpdateChatData(chatId, MessageStatusDefault, timezone)
+	if err != nil { return err }
+	err = reloadJobsForChat(chatId)
+	if err == nil {
+		return nil
+	}
+	restoreErr := storage.UpdateChatData(chatId, oldStatus, oldTimezone)
+	if restoreErr == nil {
+		restoreErr = reloadJobsForChat(chatId)
+	}
+	if restoreErr != nil {
+		sendErrorReport(restoreErr, "error restoring chat jobs after timezone change")
+		println(restoreErr.Error())
+	}
+	return err
+}
+
 func removeCronForChat(chatId int64, cron string) error {
 	cron = strings.Trim(cron, " ")
-	err := dbRemoveCron(chatId, cron)
+	err := storage.RemoveCron(chatId, cron)
 	if err != nil { return err }
-	scheduler.RemoveJob(chatsCronJobsIds[chatId][cron])
+	jobRegistry.removeSchedule(chatId, JobKindCron, cron)
 	return nil
 }
 
@@ -480,15 +706,10 @@ func getTimezoneByLocation(location Location) (string, error) {
 	return respData.Timezone, nil
 }
 
-var chooseTimezoneKeyboard = ReplyKeyboardMarkup{[][]KeyboardButton{{{ "Определить время по геопозиции", true }}, 
-	{{"UTC+0", false}, {"UTC+1", false}, {"UTC+2", false}, {"UTC+3", false}},
-	{{"UTC+4", false}, {"UTC+5", false}, {"UTC+6", false}, {"UTC+7", false}},
-	{{"UTC+8", false}, {"UTC+9", false}, {"UTC+10", false}, {"UTC+11", false}},
-	{{"UTC+12", false}, {"UTC+13", false}, {"UTC+14", false}},
-	{{"UTC-1", false}, {"UTC-2", false}, {"UTC-3", false}, {"UTC-4", false}},
-	{{"UTC-5", false}, {"UTC-6", false}, {"UTC-7", false}, {"UTC-8", false}},
-	{{"UTC-9", false}, {"UTC-10", false}, {"UTC-11", false}, {"UTC-12", false}},
-}}
+func chooseTimezoneKeyboard(lang Language) ReplyKeyboardMarkup {
+	keyboard := [][]KeyboardButton{{{tr(lang, "button_location"), true}}}
+	return ReplyKeyboardMarkup{append(keyboard, chooseTimezoneKeyboardNoLocation.Keyboard...)}
+}
 
 var chooseTimezoneKeyboardNoLocation = ReplyKeyboardMarkup{[][]KeyboardButton{ 
 	{{"UTC+0", false}, {"UTC+1", false}, {"UTC+2", false}, {"UTC+3", false}},
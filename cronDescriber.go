@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Descriptions that would list more exact times or days than this fall back to the cron string.
+const maxListedCronTimes = 6
+const maxListedMonthDays = 5
+
+// cronStep returns the common difference of values, or 0 if they are not an arithmetic progression.
+func cronStep(values []int) int {
+	if len(values) < 2 {
+		return 0
+	}
+	step := values[1] - values[0]
+	for i := 2; i < len(values); i++ {
+		if values[i]-values[i-1] != step {
+			return 0
+		}
+	}
+	return step
+}
+
+func joinList(lang Language, items []string) string {
+	if len(items) <= 1 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + tr(lang, "list_and") + items[len(items)-1]
+}
+
+func capitalize(text string) string {
+	r, size := utf8.DecodeRuneInString(text)
+	return string(unicode.ToUpper(r)) + text[size:]
+}
+
+// describeCronTime describes the minute and hour fields. The second result is true
+// when the description is a list of exact times, which reads better after the days.
+func describeCronTime(minutes []int, hours []int, lang Language) (string, bool, bool) {
+	if len(minutes)*len(hours) <= maxListedCronTimes {
+		times := []string{}
+		for _, hour := range hours {
+			for _, minute := range minutes {
+				times = append(times, timeToString(hour*60+minute))
+			}
+		}
+		return tr(lang, "cron_at", joinList(lang, times)), true, true
+	}
+	hoursStep := cronStep(hours)
+	hoursFull := len(hours) == 24
+	if len(minutes) == 1 {
+		if hoursFull {
+			return tr(lang, "cron_every_hour_at", minutes[0]), false, true
+		}
+		if hoursStep == 0 {
+			return "", false, false
+		}
+		every := tr(lang, "cron_every_hour")
+		if hoursStep > 1 {
+			every = trn(lang, "cron_every_n_hours", hoursStep)
+		}
+		from := timeToString(hours[0]*60 + minutes[0])
+		to := timeToString(hours[len(hours)-1]*60 + minutes[0])
+		return every + " " + tr(lang, "cron_from_to", from, to), false, true
+	}
+	minutesStep := cronStep(minutes)
+	if minutesStep == 0 || 60%minutesStep != 0 || minutes[0] >= minutesStep || (!hoursFull && hoursStep != 1) {
+		return "", false, false
+	}
+	every := tr(lang, "cron_every_minute")
+	if minutesStep > 1 {
+		every = trn(lang, "cron_every_n_minutes", minutesStep)
+	}
+	if hoursFull {
+		return every, false, true
+	}
+	from := timeToString(hours[0]*60 + minutes[0])
+	to := timeToString(hours[len(hours)-1]*60 + minutes[len(minutes)-1])
+	return every + " " + tr(lang, "cron_from_to", from, to), false, true
+}
+
+func describeCronWeekDays(schedule CronSchedule, lang Language) string {
+	// Weeks start on Monday in descriptions, so Sunday goes last.
+	days := []int{}
+	for i := 1; i <= 7; i++ {
+		if schedule.has(cronWeekDay, i%7) {
+			days = append(days, i)
+		}
+	}
+	if len(days) == 5 && days[0] == 1 && days[4] == 5 {
+		return tr(lang, "cron_on_work_days")
+	}
+	if len(days) == 2 && days[0] == 6 && days[1] == 7 {
+		return tr(lang, "cron_on_weekends")
+	}
+	if len(days) >= 3 && cronStep(days) == 1 {
+		return tr(lang, fmt.Sprintf("weekday_from_%d", days[0]%7)) + " " +
+			tr(lang, fmt.Sprintf("weekday_to_%d", days[len(days)-1]%7))
+	}
+	names := []string{}
+	for _, day := range days {
+		names = append(names, tr(lang, fmt.Sprintf("weekday_plural_%d", day%7)))
+	}
+	return tr(lang, "cron_on_weekdays", joinList(lang, names))
+}
+
+func describeCronMonthDays(schedule CronSchedule, lang Language) (string, bool) {
+	days := schedule.values(cronDay)
+	if len(days) >= 3 && cronStep(days) == 1 {
+		return tr(lang, "cron_month_day_range", days[0], days[len(days)-1]), true
+	}
+	if len(days) > maxListedMonthDays {
+		return "", false
+	}
+	numbers := []string{}
+	for _, day := range days {
+		numbers = append(numbers, strconv.Itoa(day))
+	}
+	if len(numbers) == 1 {
+		return tr(lang, "cron_month_day", numbers[0]), true
+	}
+	return tr(lang, "cron_month_days", joinList(lang, numbers)), true
+}
+
+func describeCronMonths(schedule CronSchedule, lang Language) string {
+	months := schedule.values(cronMonth)
+	if len(months) >= 3 && cronStep(months) == 1 {
+		return tr(lang, "cron_month_range", tr(lang, fmt.Sprintf("month_gen_%d", months[0])),
+			tr(lang, fmt.Sprintf("month_nom_%d", months[len(months)-1])))
+	}
+	names := []string{}
+	for _, month := range months {
+		names = append(names, tr(lang, fmt.Sprintf("month_prep_%d", month)))
+	}
+	return tr(lang, "cron_in_months", joinList(lang, names))
+}
+
+// describeCron renders a schedule in natural language. The second result is false
+// when there is no readable description and the cron string itself should be shown.
+func describeCron(schedule CronSchedule, lang Language) (string, bool) {
+	minutes := schedule.values(cronMinute)
+	hours := schedule.values(cronHour)
+	monthsFull := schedule.isFull(cronMonth)
+	dayRestricted := !schedule.isFull(cronDay)
+	weekDayRestricted := !schedule.isFull(cronWeekDay)
+	if !schedule.DayStar && !schedule.WeekDayStar && (!dayRestricted || !weekDayRestricted) {
+		// Either field matching is enough here, so a full one means every day.
+		dayRestricted, weekDayRestricted = false, false
+	}
+	if dayRestricted && weekDayRestricted && (schedule.DayStar || schedule.WeekDayStar) {
+		return "", false
+	}
+
+	if len(minutes) == 60 && len(hours) == 24 && !dayRestricted && !weekDayRestricted && monthsFull {
+		return tr(lang, "cron_every_minute"), true
+	}
+	if len(minutes) == 1 && len(hours) == 1 && !dayRestricted && monthsFull {
+		hour := fmt.Sprintf("%02d", hours[0])
+		minute := fmt.Sprintf("%02d", minutes[0])
+		weekDays := schedule.values(cronWeekDay)
+		if !weekDayRestricted {
+			return tr(lang, "cron_every_day_at", hour, minute), true
+		}
+		if len(weekDays) == 1 {
+			return tr(lang, "cron_weekday_at", tr(lang, fmt.Sprintf("weekday_%d", weekDays[0])), hour, minute), true
+		}
+	}
+
+	timePart, exactTimes, ok := describeCronTime(minutes, hours, lang)
+	if !ok {
+		return "", false
+	}
+	daysPart := ""
+	monthsPart := ""
+	if !monthsFull {
+		monthsPart = describeCronMonths(schedule, lang)
+	}
+	if dayRestricted {
+		monthDays := schedule.values(cronDay)
+		months := schedule.values(cronMonth)
+		if len(monthDays) == 1 && len(months) == 1 && !weekDayRestricted {
+			daysPart = tr(lang, "cron_date", monthDays[0], tr(lang, fmt.Sprintf("month_gen_%d", months[0])))
+			monthsPart = ""
+		} else {
+			daysPart, ok = describeCronMonthDays(schedule, lang)
+			if !ok {
+				return "", false
+			}
+		}
+	}
+	if weekDayRestricted {
+		weekDaysPart := describeCronWeekDays(schedule, lang)
+		if daysPart != "" {
+			daysPart += " " + tr(lang, "cron_or") + " " + weekDaysPart
+		} else {
+			daysPart = weekDaysPart
+		}
+	}
+	if daysPart == "" && exactTimes {
+		daysPart = tr(lang, "cron_every_day")
+	}
+
+	parts := []string{}
+	if exactTimes {
+		parts = append(parts, daysPart, timePart, monthsPart)
+	} else {
+		parts = append(parts, timePart, daysPart, monthsPart)
+	}
+	nonEmpty := []string{}
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return capitalize(strings.Join(nonEmpty, " ")), true
+}
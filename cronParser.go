@@ -0,0 +1,292 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const minCronInterval = 30 * time.Minute
+
+var errInvalidCron = errors.New("invalid cron expression")
+var errCronTooFrequent = errors.New("cron fires too often")
+
+// cronField describes the values of a field. stepMax is where "*/step" and "N/step"
+// stop: Sunday is both 0 and 7 in the day of week field, but steps stop at Saturday
+// there, as in robfig/cron.
+type cronField struct {
+	min     int
+	max     int
+	stepMax int
+	names   []string
+}
+
+var cronFields = []cronField{
+	{0, 59, 59, nil},
+	{0, 23, 23, nil},
+	{1, 31, 31, nil},
+	{1, 12, 12, []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}},
+	{0, 7, 6, []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}},
+}
+
+const (
+	cronMinute = iota
+	cronHour
+	cronDay
+	cronMonth
+	cronWeekDay
+)
+
+// CronSchedule is a parsed standard 5-field cron expression.
+// Every field is a bit set of the allowed values, Sunday is always 0.
+type CronSchedule struct {
+	Fields [5]uint64
+	// DayStar and WeekDayStar are set when the field was given as "*" or "?" without
+	// a step, which matters for the day of month / day of week "or" rule. As in gocron,
+	// "*/2" is not a star.
+	DayStar     bool
+	WeekDayStar bool
+}
+
+func (field cronField) parseValue(value string) (int, error) {
+	for i, name := range field.names {
+		if strings.EqualFold(value, name) {
+			return i + field.min, nil
+		}
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil || i < field.min || i > field.max {
+		return 0, errInvalidCron
+	}
+	return i, nil
+}
+
+func (field cronField) parse(part string) (uint64, error) {
+	var result uint64
+	for _, item := range strings.Split(part, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 || step > field.max {
+				return 0, errInvalidCron
+			}
+		}
+		var start, end int
+		if rangePart == "*" || rangePart == "?" {
+			start, end = field.min, field.max
+			if hasStep {
+				end = field.stepMax
+			}
+		} else if startPart, endPart, isRange := strings.Cut(rangePart, "-"); isRange {
+			var err1, err2 error
+			start, err1 = field.parseValue(startPart)
+			end, err2 = field.parseValue(endPart)
+			if err1 != nil || err2 != nil || start > end {
+				return 0, errInvalidCron
+			}
+		} else {
+			var err error
+			start, err = field.parseValue(rangePart)
+			if err != nil {
+				return 0, errInvalidCron
+			}
+			end = start
+			if hasStep {
+				end = field.stepMax
+			}
+		}
+		for i := start; i <= end; i += step {
+			result |= 1 << i
+		}
+	}
+	return result, nil
+}
+
+func parseCron(cron string) (CronSchedule, error) {
+	parts := strings.Fields(cron)
+	if len(parts) != len(cronFields) {
+		return CronSchedule{}, errInvalidCron
+	}
+	var schedule CronSchedule
+	for i, part := range parts {
+		set, err := cronFields[i].parse(part)
+		if err != nil {
+			return CronSchedule{}, err
+		}
+		schedule.Fields[i] = set
+	}
+	if schedule.Fields[cronWeekDay]&(1<<7) != 0 {
+		schedule.Fields[cronWeekDay] = schedule.Fields[cronWeekDay]&^(1<<7) | 1
+	}
+	schedule.DayStar = isStarField(parts[cronDay])
+	schedule.WeekDayStar = isStarField(parts[cronWeekDay])
+	return schedule, nil
+}
+
+func isStarField(part string) bool {
+	for _, item := range strings.Split(part, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		if (rangePart == "*" || rangePart == "?") && (!hasStep || stepPart == "1") {
+			return true
+		}
+	}
+	return false
+}
+
+func (schedule CronSchedule) has(field int, value int) bool {
+	return schedule.Fields[field]&(1<<value) != 0
+}
+
+func (schedule CronSchedule) values(field int) []int {
+	result := []int{}
+	for i := cronFields[field].min; i <= cronFields[field].max; i++ {
+		if schedule.has(field, i) {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+func (schedule CronSchedule) isFull(field int) bool {
+	maxValue := cronFields[field].max
+	if field == cronWeekDay {
+		maxValue = 6
+	}
+	for i := cronFields[field].min; i <= maxValue; i++ {
+		if !schedule.has(field, i) {
+			return false
+		}
+	}
+	return true
+}
+
+func (schedule CronSchedule) dayMatches(t time.Time) bool {
+	day := schedule.has(cronDay, t.Day())
+	weekDay := schedule.has(cronWeekDay, int(t.Weekday()))
+	if schedule.DayStar || schedule.WeekDayStar {
+		return day && weekDay
+	}
+	return day || weekDay
+}
+
+// Next returns the first fire time after t in t's location, or zero time if there is none within 5 years.
+func (schedule CronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	advance := func(current time.Time, next time.Time, fallback time.Duration) time.Time {
+		if next.After(current) {
+			return next
+		}
+		return current.Add(fallback)
+	}
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc)
+	t = advance(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc), time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if !schedule.has(cronMonth, int(t.Month())) {
+			t = advance(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc), time.Hour)
+			continue
+		}
+		if !schedule.dayMatches(t) {
+			t = advance(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc), time.Hour)
+			continue
+		}
+		if !schedule.has(cronHour, t.Hour()) {
+			t = advance(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc), time.Minute)
+			continue
+		}
+		if !schedule.has(cronMinute, t.Minute()) {
+			t = advance(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc), time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func formatCronField(schedule CronSchedule, field int) string {
+	if schedule.isFull(field) {
+		return "*"
+	}
+	values := schedule.values(field)
+	if len(values) >= 3 {
+		step := values[1] - values[0]
+		progression := step > 1
+		for i := 2; i < len(values) && progression; i++ {
+			progression = values[i]-values[i-1] == step
+		}
+		if progression {
+			start, end := values[0], values[len(values)-1]
+			if start == cronFields[field].min && end+step > cronFields[field].max {
+				return "*/" + strconv.Itoa(step)
+			}
+			return strconv.Itoa(start) + "-" + strconv.Itoa(end) + "/" + strconv.Itoa(step)
+		}
+	}
+	parts := []string{}
+	for i := 0; i < len(values); {
+		j := i
+		for j+1 < len(values) && values[j+1] == values[j]+1 {
+			j++
+		}
+		if j > i {
+			parts = append(parts, strconv.Itoa(values[i])+"-"+strconv.Itoa(values[j]))
+		} else {
+			parts = append(parts, strconv.Itoa(values[i]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
+
+// String returns the normalised form of the schedule: numbers only, Sunday as 0,
+// ranges and steps where they make the expression shorter.
+func (schedule CronSchedule) String() string {
+	parts := []string{}
+	for field := range cronFields {
+		part := formatCronField(schedule, field)
+		if field == cronDay && !schedule.DayStar && part == "*" {
+			part = "1-31"
+		}
+		if field == cronWeekDay && !schedule.WeekDayStar && part == "*" {
+			part = "0-6"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " ")
+}
+
+// minInterval returns the shortest gap between consecutive fires, looking at the next few hundred of them.
+func (schedule CronSchedule) minInterval(from time.Time) time.Duration {
+	result := time.Duration(0)
+	prev := schedule.Next(from)
+	for i := 0; i < 500 && !prev.IsZero(); i++ {
+		next := schedule.Next(prev)
+		if next.IsZero() {
+			break
+		}
+		if result == 0 || next.Sub(prev) < result {
+			result = next.Sub(prev)
+		}
+		prev = next
+	}
+	return result
+}
+
+// normalizeCron parses and validates a cron expression and returns it in the normalised form.
+func normalizeCron(cron string) (string, error) {
+	schedule, err := parseCron(cron)
+	if err != nil {
+		return "", err
+	}
+	if schedule.Next(scheduler.Now()).IsZero() {
+		return "", errInvalidCron
+	}
+	interval := schedule.minInterval(scheduler.Now())
+	if interval != 0 && interval < minCronInterval {
+		return "", errCronTooFrequent
+	}
+	return schedule.String(), nil
+}
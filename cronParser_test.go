@@ -0,0 +1,86 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/robfig/cron/v3"
+)
+
+// useFakeClock gives normalizeCron a scheduler clock without starting any jobs.
+func useFakeClock(t *testing.T, at time.Time) {
+	t.Helper()
+	var err error
+	scheduler, err = newScheduler(clockwork.NewFakeClockAt(at))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// The parser has to agree with robfig/cron, which gocron uses to run the crons.
+// gocron is given the normalised form, so that is what robfig parses here. Where
+// robfig accepts the spec as written, it has to agree on that too.
+func TestParseCronMatchesRobfig(t *testing.T) {
+	crons := []string{
+		"0 9 * * *",
+		"0 9 */2 * 1",
+		"0 9 * * 1",
+		"0 9 1 * *",
+		"0 9 1,15 * MON",
+		"30 8 */3 * */2",
+		"0 9 ? * 1-5",
+		"0 9 */1 * 1",
+		"0 9 *,1 * 1",
+		"0 12 13 * 5",
+		"15 7 1-10/2 2,3 0",
+		"0 9 * * 7",
+		"0 9 * * 1/2",
+		"0 9 * * 0/4",
+		"0 9 * * */3",
+	}
+	from := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+	for _, spec := range crons {
+		schedule, err := parseCron(spec)
+		if err != nil {
+			t.Fatalf("parseCron(%q): %v", spec, err)
+		}
+		expected, err := cron.ParseStandard(schedule.String())
+		if err != nil {
+			t.Fatalf("robfig ParseStandard(%q): %v", schedule.String(), err)
+		}
+		compareFires(t, spec, schedule, expected, from)
+		if written, err := cron.ParseStandard(spec); err == nil {
+			compareFires(t, spec, schedule, written, from)
+		}
+	}
+}
+
+func compareFires(t *testing.T, spec string, schedule CronSchedule, expected cron.Schedule, from time.Time) {
+	t.Helper()
+	got, want := from, from
+	for i := 0; i < 50; i++ {
+		got = schedule.Next(got)
+		want = expected.Next(want)
+		if !got.Equal(want) {
+			t.Fatalf("%q fire %d: got %v, want %v", spec, i, got, want)
+		}
+	}
+}
+
+func TestNormalizeCronKeepsStepNotStar(t *testing.T) {
+	useFakeClock(t, time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC))
+	for spec, want := range map[string]string{
+		"0 9 */2 * 1": "0 9 */2 * 1",
+		"0 9 * * 1":   "0 9 * * 1",
+		"0 9 */1 * 1": "0 9 * * 1",
+	} {
+		got, err := normalizeCron(spec)
+		if err != nil {
+			t.Fatalf("normalizeCron(%q): %v", spec, err)
+		}
+		if got != want {
+			t.Errorf("normalizeCron(%q) = %q, want %q", spec, got, want)
+		}
+	}
+}
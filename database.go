@@ -3,15 +3,16 @@ package main
 import (
 	"database/sql"
 	"os"
-	"sync"
+	"slices"
 	"time"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 var connStr = os.Getenv("DB_CONNECT_STRING")
 var database *sql.DB
 
-var dbMutex sync.Mutex
+// postgresStorage keeps everything in the Postgres database at DB_CONNECT_STRING.
+type postgresStorage struct{}
 
 func connectToDb() error {
 	var err error
@@ -24,7 +25,25 @@ func handleDbError(err error) {
 	sendErrorReport(err, "Ошибка при работе с базой данных")
 }
 
-func dbGetMessageStatus(chatId int64) (MessageStatus, error) {
+// withTx runs the statements of fn in one transaction, committing it if fn succeeds.
+func withTx(fn func(tx *sql.Tx) error) error {
+	tx, err := database.Begin()
+	if err != nil {
+		handleDbError(err)
+		return err
+	}
+	defer tx.Rollback()
+	err = fn(tx)
+	if err == nil {
+		err = tx.Commit()
+	}
+	if err != nil {
+		handleDbError(err)
+	}
+	return err
+}
+
+func (postgresStorage) GetMessageStatus(chatId int64) (MessageStatus, error) {
 	result := database.QueryRow("select message_status from chat where id = $1;", chatId)
 	var status MessageStatus
 	err := result.Scan(&status)
@@ -34,7 +53,7 @@ func dbGetMessageStatus(chatId int64) (MessageStatus, error) {
 	return status, err
 }
 
-func dbUpdateMessageStatus(chatId int64, messageStatus MessageStatus) error {
+func (postgresStorage) UpdateMessageStatus(chatId int64, messageStatus MessageStatus) error {
 	_, err := database.Exec("update chat set message_status = $1 where id = $2;", messageStatus, chatId)
 	if err != nil {
 		handleDbError(err)
@@ -42,15 +61,64 @@ func dbUpdateMessageStatus(chatId int64, messageStatus MessageStatus) error {
 	return err
 }
 
-func dbUpdateChatData(chatId int64, messageStatus MessageStatus, timezone string) error {
-	_, err := database.Exec("update chat set message_status = $1, timezone = $2 where id = $3;", messageStatus, timezone, chatId)
+// UpdateChatData sets the timezone and drops the random time sends planned in the old one.
+func (postgresStorage) UpdateChatData(chatId int64, messageStatus MessageStatus, timezone string) error {
+	return withTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec("update chat set message_status = $1, timezone = $2 where id = $3;", messageStatus, timezone, chatId)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec("delete from next_sends where random_time_id in (select id from random_time_verses where chat_id = $1);", chatId)
+		return err
+	})
+}
+
+func (postgresStorage) GetPendingInput(chatId int64) (string, error) {
+	row := database.QueryRow("select pending_input from chat where id = $1;", chatId)
+	var pendingInput string
+	err := row.Scan(&pendingInput)
+	if err != nil {
+		handleDbError(err)
+	}
+	return pendingInput, err
+}
+
+func (postgresStorage) UpdateMessageStatusAndPendingInput(chatId int64, messageStatus MessageStatus, pendingInput string) error {
+	_, err := database.Exec("update chat set message_status = $1, pending_input = $2 where id = $3;", messageStatus, pendingInput, chatId)
+	if err != nil {
+		handleDbError(err)
+	}
+	return err
+}
+
+func (postgresStorage) GetPause(chatId int64) (bool, sql.NullTime, error) {
+	row := database.QueryRow("select paused, paused_until from chat where id = $1;", chatId)
+	var paused bool
+	var pausedUntil sql.NullTime
+	err := row.Scan(&paused, &pausedUntil)
+	if err != nil {
+		handleDbError(err)
+	}
+	return paused, pausedUntil, err
+}
+
+func (postgresStorage) UpdatePause(chatId int64, paused bool, pausedUntil sql.NullTime) error {
+	_, err := database.Exec("update chat set paused = $1, paused_until = $2 where id = $3;", paused, pausedUntil, chatId)
 	if err != nil {
 		handleDbError(err)
 	}
 	return err
 }
 
-func dbGetTimezone(chatId int64) (string, error) {
+func (postgresStorage) ClearExpiredPauses() error {
+	_, err := database.Exec("update chat set paused = false, paused_until = null where paused and paused_until < now();")
+	if err != nil {
+		handleDbError(err)
+	}
+	return err
+}
+
+func (postgresStorage) GetTimezone(chatId int64) (string, error) {
 	row := database.QueryRow("select timezone from chat where id = $1;", chatId)
 	var timezone string
 	err := row.Scan(&timezone)
@@ -60,7 +128,7 @@ func dbGetTimezone(chatId int64) (string, error) {
 	return timezone, err
 }
 
-func dbAddCron(chatId int64, cron string) error {
+func (postgresStorage) AddCron(chatId int64, cron string) error {
 	_, err := database.Exec("insert into verses_cron(chat_id, cron) values ($1, $2);", chatId, cron)
 	if err != nil {
 		handleDbError(err)
@@ -68,7 +136,7 @@ func dbAddCron(chatId int64, cron string) error {
 	return err
 }
 
-func dbGetAllCrons(chatId int64) ([]string, error) {
+func (postgresStorage) GetAllCrons(chatId int64) ([]string, error) {
 	rows, err := database.Query("select cron from verses_cron where chat_id = $1;", chatId)
 	if err != nil {
 		handleDbError(err)
@@ -87,23 +155,89 @@ func dbGetAllCrons(chatId int64) ([]string, error) {
 	return arr, nil
 }
 
-func dbRemoveCron(chatId int64, cron string) error {
-	_, err := database.Exec("delete from verses_cron where chat_id = $1 and cron = $2;", chatId, cron)
+func (postgresStorage) GetCronsLastSent(chatId int64) (map[string]time.Time, error) {
+	rows, err := database.Query("select cron, last_sent from verses_cron where chat_id = $1;", chatId)
+	if err != nil {
+		handleDbError(err)
+		return nil, err
+	}
+	result := make(map[string]time.Time)
+	for rows.Next() {
+		var cron string
+		var lastSent time.Time
+		err = rows.Scan(&cron, &lastSent)
+		if err != nil {
+			handleDbError(err)
+			return nil, err
+		}
+		result[cron] = lastSent
+	}
+	return result, nil
+}
+
+func (postgresStorage) UpdateCronLastSent(chatId int64, cron string, lastSent time.Time) error {
+	_, err := database.Exec("update verses_cron set last_sent = $1 where chat_id = $2 and cron = $3;", lastSent, chatId, cron)
+	if err != nil {
+		handleDbError(err)
+	}
+	return err
+}
+
+func (postgresStorage) GetCronsDates(chatId int64) (map[string]DateBounds, error) {
+	rows, err := database.Query("select cron, starts_on, ends_on from verses_cron where chat_id = $1;", chatId)
+	if err != nil {
+		handleDbError(err)
+		return nil, err
+	}
+	result := make(map[string]DateBounds)
+	for rows.Next() {
+		var cron string
+		var dates DateBounds
+		err = rows.Scan(&cron, &dates.StartsOn, &dates.EndsOn)
+		if err != nil {
+			handleDbError(err)
+			return nil, err
+		}
+		result[cron] = dates
+	}
+	return result, nil
+}
+
+func (postgresStorage) UpdateCronDates(chatId int64, cron string, dates DateBounds) error {
+	_, err := database.Exec("update verses_cron set starts_on = $1, ends_on = $2 where chat_id = $3 and cron = $4;",
+		dates.StartsOn, dates.EndsOn, chatId, cron)
 	if err != nil {
 		handleDbError(err)
 	}
 	return err
 }
 
-func dbRemoveAllCronsForChat(chatId int64) error {
-	_, err := database.Exec("delete from verses_cron where chat_id = $1;", chatId)
+func (postgresStorage) ReplaceCron(chatId int64, oldCron string, newCron string) error {
+	result, err := database.Exec("update verses_cron set cron = $1 where chat_id = $2 and cron = $3;", newCron, chatId, oldCron)
+	if err != nil {
+		handleDbError(err)
+		return err
+	}
+	replaced, err := result.RowsAffected()
+	if err != nil {
+		handleDbError(err)
+		return err
+	}
+	if replaced == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (postgresStorage) RemoveCron(chatId int64, cron string) error {
+	_, err := database.Exec("delete from verses_cron where chat_id = $1 and cron = $2;", chatId, cron)
 	if err != nil {
 		handleDbError(err)
 	}
 	return err
 }
 
-func dbStatPlusOne(date string, stat string) error {
+func (postgresStorage) StatPlusOne(date string, stat string) error {
 	_, err := database.Exec("insert into stats(date, name, count) values ($1, $2, 1) on conflict (date, name) do update set count = stats.count + 1;", date, stat)
 	if err != nil {
 		handleDbError(err)
@@ -111,7 +245,7 @@ func dbStatPlusOne(date string, stat string) error {
 	return err
 }
 
-func dbStatUpdateChatsList(date string, stat string, chatId int64) error {
+func (postgresStorage) StatUpdateChatsList(date string, stat string, chatId int64) error {
 	_, err := database.Exec("insert into stats_list_chats(date, name, chat_id) values ($1, $2, $3) on conflict do nothing;", date, stat, chatId)
 	if err != nil {
 		handleDbError(err)
@@ -119,17 +253,83 @@ func dbStatUpdateChatsList(date string, stat string, chatId int64) error {
 	return err
 }
 
-func dbAddChat(chatId int64, chatType TelegramChatType) error {
+func (postgresStorage) AddChat(chatId int64, chatType TelegramChatType, language Language) error {
 	_, err := database.Exec(
-		"insert into chat(id, type) values ($1, $2) on conflict (id) do update set type = excluded.type;",
-		chatId, chatTypeToInt(chatType))
+		"insert into chat(id, type, language) values ($1, $2, $3) on conflict (id) do update set type = excluded.type, "+
+			"language = case when chat.language = '' then excluded.language else chat.language end;",
+		chatId, chatTypeToInt(chatType), language)
 	if err != nil {
 		handleDbError(err)
 	}
 	return err
 }
 
-func dbGetAllChats() ([]int64, error) {
+func (postgresStorage) GetLanguage(chatId int64) (Language, error) {
+	row := database.QueryRow("select language from chat where id = $1;", chatId)
+	var code string
+	err := row.Scan(&code)
+	if err != nil {
+		handleDbError(err)
+		return defaultLanguage, err
+	}
+	lang, ok := parseLanguage(code)
+	if !ok {
+		return defaultLanguage, nil
+	}
+	return lang, nil
+}
+
+func (postgresStorage) UpdateLanguage(chatId int64, language Language) error {
+	_, err := database.Exec("update chat set language = $1 where id = $2;", language, chatId)
+	if err != nil {
+		handleDbError(err)
+	}
+	return err
+}
+
+func (postgresStorage) GetCalendarTradition(chatId int64) (CalendarTradition, error) {
+	row := database.QueryRow("select calendar from chat where id = $1;", chatId)
+	var value string
+	err := row.Scan(&value)
+	if err != nil {
+		handleDbError(err)
+		return "", err
+	}
+	tradition, _ := parseCalendarTradition(value)
+	return tradition, nil
+}
+
+func (postgresStorage) UpdateCalendarTradition(chatId int64, tradition CalendarTradition) error {
+	_, err := database.Exec("update chat set calendar = $1 where id = $2;", tradition, chatId)
+	if err != nil {
+		handleDbError(err)
+	}
+	return err
+}
+
+func (postgresStorage) GetVerseSource(chatId int64) (VerseSource, error) {
+	row := database.QueryRow("select verse_source from chat where id = $1;", chatId)
+	var value string
+	err := row.Scan(&value)
+	if err != nil {
+		handleDbError(err)
+		return VerseSourceRandom, err
+	}
+	if VerseSource(value) == VerseSourceCalendar {
+		return VerseSourceCalendar, nil
+	}
+	return VerseSourceRandom, nil
+}
+
+func (postgresStorage) UpdateVerseSource(chatId int64, source VerseSource) error {
+	_, err := database.Exec("update chat set verse_source = $1 where id = $2;", source, chatId)
+	if err != nil {
+		handleDbError(err)
+	}
+	return err
+}
+
+func (postgresStorage) GetAllChats() ([]int64, error) {
 	rows, err := database.Query("select id from chat;")
 	if err != nil {
 		handleDbError(err)
@@ -148,8 +348,8 @@ func dbGetAllChats() ([]int64, error) {
 	return result, nil
 }
 
-func dbGetAllRandomTimes(chatId int64) ([]RandomTimeVerse, error) {
-	rows, err := database.Query("select id, weekday, start_time, duration from random_time_verses where chat_id = $1;", chatId)
+func (postgresStorage) GetAllRandomTimes(chatId int64) ([]RandomTimeVerse, error) {
+	rows, err := database.Query("select id, weekday, start_time, duration, count, starts_on, ends_on from random_time_verses where chat_id = $1;", chatId)
 	if err != nil {
 		handleDbError(err)
 		return []RandomTimeVerse{}, err
@@ -160,7 +360,9 @@ func dbGetAllRandomTimes(chatId int64) ([]RandomTimeVerse, error) {
 		var weekday int
 		var start_time int
 		var duration int
-		err = rows.Scan(&id, &weekday, &start_time, &duration)
+		var count int
+		var dates DateBounds
+		err = rows.Scan(&id, &weekday, &start_time, &duration, &count, &dates.StartsOn, &dates.EndsOn)
 		if err != nil {
 			handleDbError(err)
 			return []RandomTimeVerse{}, err
@@ -176,32 +378,17 @@ func dbGetAllRandomTimes(chatId int64) ([]RandomTimeVerse, error) {
 			rows2.Scan(&t)
 			nextSends = append(nextSends, t)
 		}
-		result = append(result, RandomTimeVerse{id, weekday, start_time, duration, nextSends})
+		result = append(result, RandomTimeVerse{id, weekday, start_time, duration, count, nextSends, dates})
 	}
 	return result, nil
 }
 
-func dbAddRandomTime(chatId int64, randomTime RandomTimeVerse) (int, error) {
-	dbMutex.Lock()
-	defer dbMutex.Unlock()
-	_, err := database.Exec("begin;")
-	defer database.Exec("rollback;")
-	if err != nil {
-		handleDbError(err)
-		return 0, err
-	}
-	row := database.QueryRow("insert into random_time_verses (id, chat_id, weekday, start_time, duration) values "+
-		"((select min_key from keys where name = 'random_time_verses'), $1, $2, $3, $4) returning id;",
-		chatId, randomTime.WeekDay, randomTime.StartTime, randomTime.Duration)
+func (postgresStorage) AddRandomTime(chatId int64, randomTime RandomTimeVerse) (int, error) {
+	row := database.QueryRow("insert into random_time_verses (chat_id, weekday, start_time, duration, count) values "+
+		"($1, $2, $3, $4, $5) returning id;",
+		chatId, randomTime.WeekDays, randomTime.StartTime, randomTime.Duration, randomTime.Count)
 	var id int
-	err = row.Scan(&id)
-	if err != nil {
-		handleDbError(err)
-		return 0, err
-	}
-	_, err = database.Exec(
-		"update keys set min_key = min_key + 1 where name = 'random_time_verses';" +
-			"commit;")
+	err := row.Scan(&id)
 	if err != nil {
 		handleDbError(err)
 		return 0, err
@@ -209,10 +396,11 @@ func dbAddRandomTime(chatId int64, randomTime RandomTimeVerse) (int, error) {
 	return id, nil
 }
 
-func dbGetRandomTimeById(randomTimeId int) (RandomTimeVerse, error) {
-	row := database.QueryRow("select weekday, start_time, duration from random_time_verses where id = $1;", randomTimeId)
-	var weekday, start_time, duration int
-	err := row.Scan(&weekday, &start_time, &duration)
+func (postgresStorage) GetRandomTimeById(randomTimeId int) (RandomTimeVerse, error) {
+	row := database.QueryRow("select weekday, start_time, duration, count, starts_on, ends_on from random_time_verses where id = $1;", randomTimeId)
+	var weekday, start_time, duration, count int
+	var dates DateBounds
+	err := row.Scan(&weekday, &start_time, &duration, &count, &dates.StartsOn, &dates.EndsOn)
 	if err != nil {
 		handleDbError(err)
 		return RandomTimeVerse{}, err
@@ -228,10 +416,26 @@ func dbGetRandomTimeById(randomTimeId int) (RandomTimeVerse, error) {
 		rows2.Scan(&t)
 		nextSends = append(nextSends, t)
 	}
-	return RandomTimeVerse{randomTimeId, weekday, start_time, duration, nextSends}, nil
+	return RandomTimeVerse{randomTimeId, weekday, start_time, duration, count, nextSends, dates}, nil
 }
 
-func dbRemoveRandomTime(chatId int64, randomTimeId int) error {
+// UpdateRandomTime changes the schedule and drops the sends planned by its old version.
+func (postgresStorage) UpdateRandomTime(chatId int64, randomTime RandomTimeVerse) error {
+	return withTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec(
+			"update random_time_verses set weekday = $1, start_time = $2, duration = $3, count = $4, starts_on = $5, ends_on = $6 "+
+				"where chat_id = $7 and id = $8;",
+			randomTime.WeekDays, randomTime.StartTime, randomTime.Duration, randomTime.Count,
+			randomTime.Dates.StartsOn, randomTime.Dates.EndsOn, chatId, randomTime.Id)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec("delete from next_sends where random_time_id = $1;", randomTime.Id)
+		return err
+	})
+}
+
+func (postgresStorage) RemoveRandomTime(chatId int64, randomTimeId int) error {
 	_, err := database.Exec(
 		"delete from random_time_verses where chat_id = $1 and id = $2;", chatId, randomTimeId)
 	if err != nil {
@@ -241,9 +445,22 @@ func dbRemoveRandomTime(chatId int64, randomTimeId int) error {
 	return nil
 }
 
-func dbRemoveAllRandomTimesForChat(chatId int64) error {
+// RemoveAllSchedulesForChat deletes every kind of schedule of the chat at once.
+func (postgresStorage) RemoveAllSchedulesForChat(chatId int64) error {
+	return withTx(func(tx *sql.Tx) error {
+		for _, table := range []string{"verses_cron", "random_time_verses", "reminders", "solar_verses"} {
+			_, err := tx.Exec("delete from "+table+" where chat_id = $1;", chatId)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (postgresStorage) AddNextSend(randomTimeId int, send time.Time) error {
 	_, err := database.Exec(
-		"delete from random_time_verses where chat_id = $1;", chatId)
+		"insert into next_sends (random_time_id, timestamp) values ($1, $2) on conflict do nothing;", randomTimeId, send)
 	if err != nil {
 		handleDbError(err)
 		return err
@@ -251,9 +468,8 @@ func dbRemoveAllRandomTimesForChat(chatId int64) error {
 	return nil
 }
 
-func dbAddNextSend(randomTimeId int, send time.Time) error {
-	_, err := database.Exec(
-		"insert into next_sends (random_time_id, timestamp) values ($1, $2) on conflict do nothing;", randomTimeId, send)
+func (postgresStorage) RemoveNextSend(randomTimeId int, send time.Time) error {
+	_, err := database.Exec("delete from next_sends where random_time_id = $1 and timestamp = $2;", randomTimeId, send)
 	if err != nil {
 		handleDbError(err)
 		return err
@@ -261,7 +477,7 @@ func dbAddNextSend(randomTimeId int, send time.Time) error {
 	return nil
 }
 
-func dbClearOldSends() error {
+func (postgresStorage) ClearOldSends() error {
 	_, err := database.Exec("delete from next_sends where timestamp < now();")
 	if err != nil {
 		handleDbError(err)
@@ -270,7 +486,306 @@ func dbClearOldSends() error {
 	return nil
 }
 
-func dbGetStatsInRange(startDate string, endDate string) ([]Stats, error) {
+func (postgresStorage) GetChatCoordinates(chatId int64) (float64, float64, bool, error) {
+	row := database.QueryRow("select latitude, longitude from chat where id = $1;", chatId)
+	var latitude, longitude sql.NullFloat64
+	err := row.Scan(&latitude, &longitude)
+	if err != nil {
+		handleDbError(err)
+		return 0, 0, false, err
+	}
+	return latitude.Float64, longitude.Float64, latitude.Valid && longitude.Valid, nil
+}
+
+func (postgresStorage) UpdateChatCoordinates(chatId int64, latitude float64, longitude float64) error {
+	_, err := database.Exec("update chat set latitude = $1, longitude = $2 where id = $3;", latitude, longitude, chatId)
+	if err != nil {
+		handleDbError(err)
+	}
+	return err
+}
+
+// ForgetChatCoordinates removes the coordinates together with the schedules that need them.
+func (postgresStorage) ForgetChatCoordinates(chatId int64) error {
+	return withTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec("delete from solar_verses where chat_id = $1;", chatId)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec("update chat set latitude = null, longitude = null where id = $1;", chatId)
+		return err
+	})
+}
+
+func (postgresStorage) AddSolarSchedule(chatId int64, solar SolarVerse) (int, error) {
+	row := database.QueryRow("insert into solar_verses (chat_id, event, offset_minutes) values ($1, $2, $3) returning id;",
+		chatId, solar.Event, solar.Offset)
+	var id int
+	err := row.Scan(&id)
+	if err != nil {
+		handleDbError(err)
+		return 0, err
+	}
+	return id, nil
+}
+
+func (postgresStorage) GetAllSolarSchedules(chatId int64) ([]SolarVerse, error) {
+	rows, err := database.Query("select id, event, offset_minutes from solar_verses where chat_id = $1 order by event, offset_minutes;", chatId)
+	if err != nil {
+		handleDbError(err)
+		return []SolarVerse{}, err
+	}
+	result := []SolarVerse{}
+	for rows.Next() {
+		var solar SolarVerse
+		err = rows.Scan(&solar.Id, &solar.Event, &solar.Offset)
+		if err != nil {
+			handleDbError(err)
+			return []SolarVerse{}, err
+		}
+		result = append(result, solar)
+	}
+	return result, nil
+}
+
+func (postgresStorage) RemoveSolarSchedule(chatId int64, solarId int) error {
+	_, err := database.Exec("delete from solar_verses where chat_id = $1 and id = $2;", chatId, solarId)
+	if err != nil {
+		handleDbError(err)
+	}
+	return err
+}
+
+func (postgresStorage) AddReminder(chatId int64, sendAt time.Time) (int, error) {
+	row := database.QueryRow("insert into reminders (chat_id, send_at) values ($1, $2) returning id;", chatId, sendAt)
+	var id int
+	err := row.Scan(&id)
+	if err != nil {
+		handleDbError(err)
+		return 0, err
+	}
+	return id, nil
+}
+
+func (postgresStorage) GetAllReminders(chatId int64) ([]Reminder, error) {
+	rows, err := database.Query("select id, send_at from reminders where chat_id = $1 order by send_at;", chatId)
+	if err != nil {
+		handleDbError(err)
+		return []Reminder{}, err
+	}
+	result := []Reminder{}
+	for rows.Next() {
+		var reminder Reminder
+		err = rows.Scan(&reminder.Id, &reminder.SendAt)
+		if err != nil {
+			handleDbError(err)
+			return []Reminder{}, err
+		}
+		result = append(result, reminder)
+	}
+	return result, nil
+}
+
+func (postgresStorage) RemoveReminder(chatId int64, reminderId int) error {
+	_, err := database.Exec("delete from reminders where chat_id = $1 and id = $2;", chatId, reminderId)
+	if err != nil {
+		handleDbError(err)
+	}
+	return err
+}
+
+// AdvanceDueCrons locks up to limit crons that are due at now or have no next fire time,
+// skipping the ones locked by other instances, and stores the next fire time returned by advance.
+func (postgresStorage) AdvanceDueCrons(now time.Time, limit int, advance func(cron DueCron) time.Time) (int, error) {
+	tx, err := database.Begin()
+	if err != nil {
+		handleDbError(err)
+		return 0, err
+	}
+	defer tx.Rollback()
+	rows, err := tx.Query("select chat_id, cron, last_sent, next_fire_at from verses_cron "+
+		"where next_fire_at is null or next_fire_at <= $1 order by next_fire_at nulls first limit $2 for update skip locked;",
+		now, limit)
+	if err != nil {
+		handleDbError(err)
+		return 0, err
+	}
+	crons := []DueCron{}
+	for rows.Next() {
+		var cron DueCron
+		err = rows.Scan(&cron.ChatId, &cron.Cron, &cron.LastSent, &cron.NextFireAt)
+		if err != nil {
+			rows.Close()
+			handleDbError(err)
+			return 0, err
+		}
+		crons = append(crons, cron)
+	}
+	rows.Close()
+	for _, cron := range crons {
+		_, err = tx.Exec("update verses_cron set next_fire_at = $1 where chat_id = $2 and cron = $3;",
+			advance(cron), cron.ChatId, cron.Cron)
+		if err != nil {
+			handleDbError(err)
+			return 0, err
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		handleDbError(err)
+		return 0, err
+	}
+	return len(crons), nil
+}
+
+// AdvanceDueSolars works like AdvanceDueCrons for sunrise and sunset schedules.
+func (postgresStorage) AdvanceDueSolars(now time.Time, limit int, advance func(solar DueSolar) time.Time) (int, error) {
+	tx, err := database.Begin()
+	if err != nil {
+		handleDbError(err)
+		return 0, err
+	}
+	defer tx.Rollback()
+	rows, err := tx.Query("select chat_id, id, event, offset_minutes, next_fire_at from solar_verses "+
+		"where next_fire_at is null or next_fire_at <= $1 order by next_fire_at nulls first limit $2 for update skip locked;",
+		now, limit)
+	if err != nil {
+		handleDbError(err)
+		return 0, err
+	}
+	solars := []DueSolar{}
+	for rows.Next() {
+		var solar DueSolar
+		err = rows.Scan(&solar.ChatId, &solar.Solar.Id, &solar.Solar.Event, &solar.Solar.Offset, &solar.NextFireAt)
+		if err != nil {
+			rows.Close()
+			handleDbError(err)
+			return 0, err
+		}
+		solars = append(solars, solar)
+	}
+	rows.Close()
+	for _, solar := range solars {
+		_, err = tx.Exec("update solar_verses set next_fire_at = $1 where id = $2;", advance(solar), solar.Solar.Id)
+		if err != nil {
+			handleDbError(err)
+			return 0, err
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		handleDbError(err)
+		return 0, err
+	}
+	return len(solars), nil
+}
+
+func (postgresStorage) ResetCronsNextFire(chatId int64) error {
+	_, err := database.Exec("update verses_cron set next_fire_at = null where chat_id = $1;", chatId)
+	if err != nil {
+		handleDbError(err)
+	}
+	return err
+}
+
+func (postgresStorage) ResetSolarsNextFire(chatId int64) error {
+	_, err := database.Exec("update solar_verses set next_fire_at = null where chat_id = $1;", chatId)
+	if err != nil {
+		handleDbError(err)
+	}
+	return err
+}
+
+func scanDueSends(rows *sql.Rows) ([]DueSend, error) {
+	defer rows.Close()
+	result := []DueSend{}
+	for rows.Next() {
+		var send DueSend
+		err := rows.Scan(&send.ChatId, &send.At)
+		if err != nil {
+			handleDbError(err)
+			return []DueSend{}, err
+		}
+		result = append(result, send)
+	}
+	return result, nil
+}
+
+// TakeDueNextSends deletes up to limit random time sends due at now and returns them.
+func (postgresStorage) TakeDueNextSends(now time.Time, limit int) ([]DueSend, error) {
+	rows, err := database.Query("delete from next_sends n using random_time_verses r "+
+		"where r.id = n.random_time_id and (n.random_time_id, n.timestamp) in "+
+		"(select random_time_id, timestamp from next_sends where timestamp <= $1 order by timestamp limit $2 for update skip locked) "+
+		"returning r.chat_id, n.timestamp;", now, limit)
+	if err != nil {
+		handleDbError(err)
+		return []DueSend{}, err
+	}
+	return scanDueSends(rows)
+}
+
+// TakeDueReminders deletes up to limit reminders due at now and returns them.
+func (postgresStorage) TakeDueReminders(now time.Time, limit int) ([]DueSend, error) {
+	rows, err := database.Query("delete from reminders where id in "+
+		"(select id from reminders where send_at <= $1 order by send_at limit $2 for update skip locked) "+
+		"returning chat_id, send_at;", now, limit)
+	if err != nil {
+		handleDbError(err)
+		return []DueSend{}, err
+	}
+	return scanDueSends(rows)
+}
+
+func (postgresStorage) ClearOldReminders() error {
+	_, err := database.Exec("delete from reminders where send_at < now() - interval '1 day';")
+	if err != nil {
+		handleDbError(err)
+	}
+	return err
+}
+
+func (postgresStorage) ClaimUpdate(updateId int64) (bool, error) {
+	result, err := database.Exec("insert into processed_updates (update_id) values ($1) on conflict (update_id) do update "+
+		"set received_at = now() where processed_updates.handled_at is null and processed_updates.received_at < now() - make_interval(secs => $2);",
+		updateId, updateClaimTimeout.Seconds())
+	if err != nil {
+		handleDbError(err)
+		return false, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		handleDbError(err)
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (postgresStorage) MarkUpdateHandled(updateId int64) error {
+	_, err := database.Exec("update processed_updates set handled_at = now() where update_id = $1;", updateId)
+	if err != nil {
+		handleDbError(err)
+	}
+	return err
+}
+
+func (postgresStorage) ReleaseUpdate(updateId int64) error {
+	_, err := database.Exec("delete from processed_updates where update_id = $1 and handled_at is null;", updateId)
+	if err != nil {
+		handleDbError(err)
+	}
+	return err
+}
+
+func (postgresStorage) ClearOldProcessedUpdates() error {
+	_, err := database.Exec("delete from processed_updates where received_at < now() - interval '2 days';")
+	if err != nil {
+		handleDbError(err)
+		return err
+	}
+	return nil
+}
+
+func (postgresStorage) GetStatsInRange(startDate string, endDate string) ([]Stats, error) {
 	rows, err := database.Query("select count, date, name from stats where date >= $1 and date <= $2 order by date;",
 		startDate, endDate)
 	if err != nil {
@@ -298,7 +813,7 @@ func dbGetStatsInRange(startDate string, endDate string) ([]Stats, error) {
 	return result, nil
 }
 
-func dbGetStatsListChatsInRange(startDate string, endDate string) ([]StatsListChats, error) {
+func (postgresStorage) GetStatsListChatsInRange(startDate string, endDate string) ([]StatsListChats, error) {
 	rows, err := database.Query("select chat_id, date, name from stats_list_chats where date >= $1 and date <= $2 order by date, name;",
 		startDate, endDate)
 	if err != nil {
@@ -325,3 +840,128 @@ func dbGetStatsListChatsInRange(startDate string, endDate string) ([]StatsListCh
 	}
 	return result, nil
 }
+
+func (postgresStorage) GetVersesList(listId int) (VersesList, error) {
+	list := VersesList{Id: listId, List: []LongVerse{}, Writers: []int64{}, Readers: []int64{}}
+	row := database.QueryRow("select title, owner_id, public from verses_lists where id = $1;", listId)
+	err := row.Scan(&list.Title, &list.OwnerId, &list.Public)
+	if err != nil {
+		handleDbError(err)
+		return VersesList{}, err
+	}
+	rows, err := database.Query("select book, chapter, verses from verses_list_entries where list_id = $1 order by id;", listId)
+	if err != nil {
+		handleDbError(err)
+		return VersesList{}, err
+	}
+	for rows.Next() {
+		var verse LongVerse
+		var verses pq.Int64Array
+		err = rows.Scan(&verse.Book, &verse.Chapter, &verses)
+		if err != nil {
+			rows.Close()
+			handleDbError(err)
+			return VersesList{}, err
+		}
+		for _, v := range verses {
+			verse.Verses = append(verse.Verses, int(v))
+		}
+		list.List = append(list.List, verse)
+	}
+	rows.Close()
+	rows, err = database.Query("select chat_id, can_write from verses_list_access where list_id = $1;", listId)
+	if err != nil {
+		handleDbError(err)
+		return VersesList{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var chatId int64
+		var canWrite bool
+		err = rows.Scan(&chatId, &canWrite)
+		if err != nil {
+			handleDbError(err)
+			return VersesList{}, err
+		}
+		if canWrite {
+			list.Writers = append(list.Writers, chatId)
+		} else {
+			list.Readers = append(list.Readers, chatId)
+		}
+	}
+	return list, nil
+}
+
+func versesArray(verses []int) pq.Int64Array {
+	result := pq.Int64Array{}
+	for _, verse := range verses {
+		result = append(result, int64(verse))
+	}
+	return result
+}
+
+// insertVersesListRows adds the entries and the access of the list.
+func insertVersesListRows(tx *sql.Tx, listId int, list VersesList) error {
+	for _, verse := range list.List {
+		_, err := tx.Exec("insert into verses_list_entries (list_id, book, chapter, verses) values ($1, $2, $3, $4);",
+			listId, verse.Book, verse.Chapter, versesArray(verse.Verses))
+		if err != nil {
+			return err
+		}
+	}
+	for _, chatIds := range [][]int64{list.Readers, list.Writers} {
+		for _, chatId := range chatIds {
+			_, err := tx.Exec("insert into verses_list_access (list_id, chat_id, can_write) values ($1, $2, $3) "+
+				"on conflict (list_id, chat_id) do update set can_write = excluded.can_write;",
+				listId, chatId, slices.Contains(list.Writers, chatId))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// importVersesListsFile copies the lists from versesLists.json into the database, keeping
+// their ids, if the database has no lists yet. The file is not needed after that.
+func importVersesListsFile() error {
+	if _, err := os.Stat(versesListsFileName); err != nil {
+		return nil
+	}
+	lists, err := readVersesListsFile()
+	if err != nil {
+		return err
+	}
+	return withTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec("lock table verses_lists in exclusive mode;")
+		if err != nil {
+			return err
+		}
+		var exists bool
+		err = tx.QueryRow("select exists (select 1 from verses_lists);").Scan(&exists)
+		if err != nil || exists {
+			return err
+		}
+		for _, list := range lists {
+			_, err = tx.Exec("insert into verses_lists (id, title, owner_id, public) values ($1, $2, $3, $4);",
+				list.Id, list.Title, list.OwnerId, list.Public)
+			if err != nil {
+				return err
+			}
+			err = insertVersesListRows(tx, list.Id, list)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = tx.Exec("select setval(pg_get_serial_sequence('verses_lists', 'id'), greatest((select max(id) from verses_lists), 1));")
+		if err != nil {
+			return err
+		}
+		println("imported verses lists", len(lists))
+		return nil
+	})
+}
+
+func (postgresStorage) Close() error {
+	return database.Close()
+}
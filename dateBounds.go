@@ -0,0 +1,133 @@
+package main
+
+import (
+	"database/sql"
+	"io"
+	"strings"
+	"time"
+)
+
+// parseDateBounds reads "2027-03-15 2027-05-01". Either date can be "-" to leave that side open,
+// and a single "-" removes both bounds.
+func parseDateBounds(input string) (DateBounds, error) {
+	fields := strings.Fields(input)
+	if len(fields) == 1 && fields[0] == "-" {
+		return DateBounds{}, nil
+	}
+	if len(fields) != 2 {
+		return DateBounds{}, io.ErrShortWrite
+	}
+	parse := func(field string) (sql.NullTime, error) {
+		if field == "-" {
+			return sql.NullTime{}, nil
+		}
+		date, err := time.Parse(time.DateOnly, field)
+		if err != nil {
+			return sql.NullTime{}, err
+		}
+		return sql.NullTime{Time: date, Valid: true}, nil
+	}
+	startsOn, err := parse(fields[0])
+	if err != nil {
+		return DateBounds{}, err
+	}
+	endsOn, err := parse(fields[1])
+	if err != nil {
+		return DateBounds{}, err
+	}
+	if startsOn.Valid && endsOn.Valid && endsOn.Time.Before(startsOn.Time) {
+		return DateBounds{}, io.ErrShortWrite
+	}
+	return DateBounds{startsOn, endsOn}, nil
+}
+
+// contains reports whether the day of t in loc is within the bounds.
+func (dates DateBounds) contains(t time.Time, loc *time.Location) bool {
+	day := t.In(loc).Format(time.DateOnly)
+	if dates.StartsOn.Valid && day < dates.StartsOn.Time.Format(time.DateOnly) {
+		return false
+	}
+	if dates.EndsOn.Valid && day > dates.EndsOn.Time.Format(time.DateOnly) {
+		return false
+	}
+	return true
+}
+
+// expired reports whether the last day of the bounds has already passed in loc.
+func (dates DateBounds) expired(now time.Time, loc *time.Location) bool {
+	return dates.EndsOn.Valid && now.In(loc).Format(time.DateOnly) > dates.EndsOn.Time.Format(time.DateOnly)
+}
+
+// start returns the first moment the bounds allow, or from itself if it is already later.
+func (dates DateBounds) start(from time.Time, loc *time.Location) time.Time {
+	if !dates.StartsOn.Valid {
+		return from
+	}
+	day := dates.StartsOn.Time
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc).Add(-time.Second)
+	if start.After(from) {
+		return start
+	}
+	return from
+}
+
+func dateBoundsToString(dates DateBounds, lang Language) string {
+	switch {
+	case dates.StartsOn.Valid && dates.EndsOn.Valid:
+		return tr(lang, "dates_from_to", dates.StartsOn.Time.Format(time.DateOnly), dates.EndsOn.Time.Format(time.DateOnly))
+	case dates.StartsOn.Valid:
+		return tr(lang, "dates_from", dates.StartsOn.Time.Format(time.DateOnly))
+	case dates.EndsOn.Valid:
+		return tr(lang, "dates_to", dates.EndsOn.Time.Format(time.DateOnly))
+	}
+	return ""
+}
+
+// withDates appends the date bounds to a schedule description, if there are any.
+func withDates(description string, dates DateBounds, lang Language) string {
+	if text := dateBoundsToString(dates, lang); text != "" {
+		return description + " (" + text + ")"
+	}
+	return description
+}
+
+func cronActiveAt(chatId int64, cron string, t time.Time) bool {
+	cronsDates, err := storage.GetCronsDates(chatId)
+	if err != nil {
+		return true
+	}
+	return cronsDates[cron].contains(t, getChatLocation(chatId))
+}
+
+// removeExpiredSchedules deletes schedules whose end date has passed.
+func removeExpiredSchedules() error {
+	chats, err := storage.GetAllChats()
+	if err != nil {
+		return err
+	}
+	now := scheduler.Now()
+	for _, chatId := range chats {
+		loc := getChatLocation(chatId)
+		cronsDates, err := storage.GetCronsDates(chatId)
+		if err != nil {
+			return err
+		}
+		for cron, dates := range cronsDates {
+			if dates.expired(now, loc) {
+				println("removing expired cron", chatId, cron)
+				removeCronForChat(chatId, cron)
+			}
+		}
+		randomTimes, err := storage.GetAllRandomTimes(chatId)
+		if err != nil {
+			return err
+		}
+		for _, rt := range randomTimes {
+			if rt.Dates.expired(now, loc) {
+				println("removing expired random time", chatId, rt.Id)
+				removeRandomTimeRegular(chatId, rt.Id)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+)
+
+type ScheduleEngine string
+
+const (
+	// ScheduleEngineJobs keeps a gocron job for every schedule on the scheduling leader.
+	ScheduleEngineJobs ScheduleEngine = "jobs"
+	// ScheduleEngineDispatcher keeps the next fire time of every schedule in the database
+	// and sends what is due from a single ticker running on every instance.
+	ScheduleEngineDispatcher ScheduleEngine = "dispatcher"
+)
+
+var scheduleEngine = getScheduleEngine()
+
+const dispatchInterval = 15 * time.Second
+const dispatchBatchSize = 100
+const maxDispatchBatches = 20
+
+// recheckInterval is how soon a schedule is looked at again when its next fire time
+// cannot be computed: the cron is broken or does not fire in the next years, the chat
+// has no coordinates, or the sun does not rise or set in the next days. Changes to the
+// schedule reset its next fire time, so they are picked up sooner.
+const recheckInterval = 24 * time.Hour
+
+func getScheduleEngine() ScheduleEngine {
+	if ScheduleEngine(os.Getenv("SCHEDULE_ENGINE")) == ScheduleEngineDispatcher {
+		return ScheduleEngineDispatcher
+	}
+	return ScheduleEngineJobs
+}
+
+func startDispatcher() error {
+	_, err := scheduler.NewJob(gocron.DurationJob(dispatchInterval), gocron.NewTask(dispatchDueSends),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule))
+	return err
+}
+
+// resetNextFires makes the dispatcher compute the next fire times of the chat's schedules again.
+func resetNextFires(chatId int64, kind JobKind) {
+	switch kind {
+	case JobKindCron:
+		storage.ResetCronsNextFire(chatId)
+	case JobKindSolar:
+		storage.ResetSolarsNextFire(chatId)
+	}
+}
+
+func dispatchDueSends() {
+	now := scheduler.Now()
+	earliest := now.Add(-getCatchUpGracePeriod())
+	for _, dispatch := range []func(time.Time, time.Time) error{
+		dispatchDueCrons, dispatchDueSolars, dispatchDueNextSends, dispatchDueReminders} {
+		err := dispatch(now, earliest)
+		if err != nil {
+			println("error dispatching sends", err.Error())
+		}
+	}
+}
+
+// fireOrRecheck returns the fire time, or a time to look again if there is none.
+// Schedules must not be left without a next fire time, as those are taken first.
+func fireOrRecheck(fire time.Time, now time.Time) time.Time {
+	if fire.IsZero() {
+		return now.Add(recheckInterval)
+	}
+	return fire
+}
+
+// recomputeFrom is where the next fire time of a schedule is searched from after a reset,
+// so that a fire which was just due is not skipped.
+func recomputeFrom(now time.Time, lastSent time.Time) time.Time {
+	from := now.Add(-2 * dispatchInterval)
+	if lastSent.After(from) {
+		return lastSent
+	}
+	return from
+}
+
+// dispatchDueCrons sends the due crons and advances them to the next fire after now.
+// Fires later than the catch up grace period are skipped.
+func dispatchDueCrons(now time.Time, earliest time.Time) error {
+	for i := 0; i < maxDispatchBatches; i++ {
+		due := []DueCron{}
+		count, err := storage.AdvanceDueCrons(now, dispatchBatchSize, func(cron DueCron) time.Time {
+			schedule, err := parseCron(cron.Cron)
+			if err != nil {
+				return now.Add(recheckInterval)
+			}
+			loc := getChatLocation(cron.ChatId)
+			fire := cron.NextFireAt.Time
+			if !cron.NextFireAt.Valid {
+				fire = schedule.Next(recomputeFrom(now, cron.LastSent).In(loc))
+				if fire.IsZero() || fire.After(now) {
+					return fireOrRecheck(fire, now)
+				}
+			}
+			if !fire.Before(earliest) {
+				due = append(due, cron)
+			}
+			return fireOrRecheck(schedule.Next(now.In(loc)), now)
+		})
+		if err != nil {
+			return err
+		}
+		for _, cron := range due {
+			cronTask(cron.ChatId, cron.Cron)
+		}
+		if count < dispatchBatchSize {
+			break
+		}
+	}
+	return nil
+}
+
+// nextSolarFire returns the first send of the schedule after from, or a time
+// to look again if there is no sunrise or sunset in the next days.
+func nextSolarFire(solar SolarVerse, latitude float64, longitude float64, loc *time.Location, from time.Time) time.Time {
+	for i := 0; i < 3; i++ {
+		send, ok := solarSendTime(from.AddDate(0, 0, i), solar, latitude, longitude, loc)
+		if ok && send.After(from) {
+			return send
+		}
+	}
+	return from.Add(recheckInterval)
+}
+
+func dispatchDueSolars(now time.Time, earliest time.Time) error {
+	for i := 0; i < maxDispatchBatches; i++ {
+		due := []DueSolar{}
+		count, err := storage.AdvanceDueSolars(now, dispatchBatchSize, func(solar DueSolar) time.Time {
+			latitude, longitude, ok, err := storage.GetChatCoordinates(solar.ChatId)
+			if err != nil || !ok {
+				return now.Add(recheckInterval)
+			}
+			loc := getChatLocation(solar.ChatId)
+			fire := solar.NextFireAt.Time
+			if !solar.NextFireAt.Valid {
+				fire = nextSolarFire(solar.Solar, latitude, longitude, loc, recomputeFrom(now, time.Time{}))
+				if fire.After(now) {
+					return fire
+				}
+			}
+			send, ok := solarSendTime(fire, solar.Solar, latitude, longitude, loc)
+			if ok && send.Equal(fire) && !fire.Before(earliest) {
+				due = append(due, solar)
+			}
+			return nextSolarFire(solar.Solar, latitude, longitude, loc, now)
+		})
+		if err != nil {
+			return err
+		}
+		for _, solar := range due {
+			randomVerseTask(solar.ChatId)
+		}
+		if count < dispatchBatchSize {
+			break
+		}
+	}
+	return nil
+}
+
+func dispatchDueNextSends(now time.Time, earliest time.Time) error {
+	return dispatchDueOneTimeSends(now, earliest, storage.TakeDueNextSends)
+}
+
+func dispatchDueReminders(now time.Time, earliest time.Time) error {
+	return dispatchDueOneTimeSends(now, earliest, storage.TakeDueReminders)
+}
+
+func dispatchDueOneTimeSends(now time.Time, earliest time.Time, take func(time.Time, int) ([]DueSend, error)) error {
+	for i := 0; i < maxDispatchBatches; i++ {
+		sends, err := take(now, dispatchBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, send := range sends {
+			if !send.At.Before(earliest) {
+				randomVerseTask(send.ChatId)
+			}
+		}
+		if len(sends) < dispatchBatchSize {
+			break
+		}
+	}
+	return nil
+}
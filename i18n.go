@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Language string
+
+const (
+	LanguageRussian   Language = "ru"
+	LanguageUkrainian Language = "uk"
+	LanguageEnglish   Language = "en"
+)
+
+const defaultLanguage = LanguageRussian
+
+var languages = []Language{LanguageRussian, LanguageUkrainian, LanguageEnglish}
+
+var languageNames = map[Language]string{
+	LanguageRussian:   "Русский",
+	LanguageUkrainian: "Українська",
+	LanguageEnglish:   "English",
+}
+
+var messages = map[Language]map[string]string{
+	LanguageRussian:   messagesRu,
+	LanguageUkrainian: messagesUk,
+	LanguageEnglish:   messagesEn,
+}
+
+var pluralMessages = map[Language]map[string][]string{
+	LanguageRussian:   pluralMessagesRu,
+	LanguageUkrainian: pluralMessagesUk,
+	LanguageEnglish:   pluralMessagesEn,
+}
+
+func parseLanguage(code string) (Language, bool) {
+	for _, lang := range languages {
+		if string(lang) == code {
+			return lang, true
+		}
+	}
+	return "", false
+}
+
+func languageFromCode(code string) Language {
+	code = strings.ToLower(code)
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+	if code == "" {
+		return ""
+	}
+	if lang, ok := parseLanguage(code); ok {
+		return lang
+	}
+	switch code {
+	case "be", "kk", "ky", "tg", "uz", "hy", "az":
+		return LanguageRussian
+	}
+	return LanguageEnglish
+}
+
+func tr(lang Language, key string, args ...any) string {
+	text, ok := messages[lang][key]
+	if !ok {
+		text = messages[defaultLanguage][key]
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
+
+// pluralForm returns 0 for the "one" form, 1 for "few" and 2 for "many"/"other".
+func pluralForm(lang Language, n int) int {
+	if n < 0 {
+		n = -n
+	}
+	switch lang {
+	case LanguageRussian, LanguageUkrainian:
+		if n%10 == 1 && n%100 != 11 {
+			return 0
+		}
+		if n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14) {
+			return 1
+		}
+		return 2
+	default:
+		if n == 1 {
+			return 0
+		}
+		return 2
+	}
+}
+
+func trn(lang Language, key string, n int) string {
+	forms, ok := pluralMessages[lang][key]
+	if !ok {
+		lang = defaultLanguage
+		forms = pluralMessages[lang][key]
+	}
+	text := forms[pluralForm(lang, n)]
+	if strings.Contains(text, "%") {
+		return fmt.Sprintf(text, n)
+	}
+	return text
+}
+
+func isTextForAnyLanguage(text string, key string) bool {
+	for _, lang := range languages {
+		if text == tr(lang, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/google/uuid"
+)
+
+type JobKind string
+
+const (
+	JobKindCron       JobKind = "cron"
+	JobKindRandomTime JobKind = "random"
+	JobKindReminder   JobKind = "reminder"
+	JobKindSolar      JobKind = "solar"
+)
+
+var scheduleJobKinds = []JobKind{JobKindCron, JobKindRandomTime, JobKindReminder, JobKindSolar}
+
+// JobKey identifies a schedule job. Schedule is the cron or the schedule id,
+// and Send is set for the one-time jobs of a schedule.
+type JobKey struct {
+	ChatId   int64
+	Kind     JobKind
+	Schedule string
+	Send     string
+}
+
+func cronJobKey(chatId int64, cron string) JobKey {
+	return JobKey{chatId, JobKindCron, cron, ""}
+}
+
+func sendJobKey(chatId int64, kind JobKind, scheduleId int, send time.Time) JobKey {
+	return JobKey{chatId, kind, strconv.Itoa(scheduleId), send.UTC().Format(time.RFC3339)}
+}
+
+// scheduleJobsTagAll tags every job of the registry.
+const scheduleJobsTagAll = "schedules"
+
+func chatJobsTag(chatId int64, kind JobKind) string {
+	return fmt.Sprintf("chat:%d:%s", chatId, kind)
+}
+
+func scheduleJobsTag(chatId int64, kind JobKind, schedule string) string {
+	return fmt.Sprintf("chat:%d:%s:%s", chatId, kind, schedule)
+}
+
+func (key JobKey) tags() []string {
+	return []string{scheduleJobsTagAll, chatJobsTag(key.ChatId, key.Kind), scheduleJobsTag(key.ChatId, key.Kind, key.Schedule)}
+}
+
+// JobRegistry owns the scheduler jobs of chat schedules. It is safe for concurrent use
+// from update workers and job tasks. Jobs are also tagged by chat, kind and schedule,
+// so removals reach jobs in the scheduler even if the registry has lost track of them.
+// Only the scheduling leader has jobs: on other instances changes are passed to the leader.
+// With the dispatcher engine there are no jobs, and changes reset the stored next fire times.
+type JobRegistry struct {
+	mutex sync.Mutex
+	jobs  map[JobKey]uuid.UUID
+}
+
+var jobRegistry = &JobRegistry{jobs: make(map[JobKey]uuid.UUID)}
+
+// add creates the job, replacing the job with the same key if there is one.
+func (registry *JobRegistry) add(key JobKey, definition gocron.JobDefinition, task gocron.Task) error {
+	if scheduleEngine == ScheduleEngineDispatcher {
+		resetNextFires(key.ChatId, key.Kind)
+		return nil
+	}
+	if !schedulingLeader.isLeader() {
+		notifySchedulesChanged(key.ChatId)
+		return nil
+	}
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	if jobId, ok := registry.jobs[key]; ok {
+		scheduler.RemoveJob(jobId)
+		delete(registry.jobs, key)
+	}
+	job, err := scheduler.NewJob(definition, task, gocron.WithTags(key.tags()...))
+	if err != nil {
+		return err
+	}
+	registry.jobs[key] = job.ID()
+	return nil
+}
+
+// replace moves the job of oldKey to newKey with a new definition and task, or creates it.
+func (registry *JobRegistry) replace(oldKey JobKey, newKey JobKey, definition gocron.JobDefinition, task gocron.Task) error {
+	if scheduleEngine == ScheduleEngineDispatcher {
+		resetNextFires(newKey.ChatId, newKey.Kind)
+		return nil
+	}
+	if !schedulingLeader.isLeader() {
+		notifySchedulesChanged(newKey.ChatId)
+		return nil
+	}
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	var job gocron.Job
+	var err error
+	if jobId, ok := registry.jobs[oldKey]; ok {
+		job, err = scheduler.Update(jobId, definition, task, gocron.WithTags(newKey.tags()...))
+	} else {
+		job, err = scheduler.NewJob(definition, task, gocron.WithTags(newKey.tags()...))
+	}
+	if err != nil {
+		return err
+	}
+	delete(registry.jobs, oldKey)
+	registry.jobs[newKey] = job.ID()
+	return nil
+}
+
+func (registry *JobRegistry) has(key JobKey) bool {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	_, ok := registry.jobs[key]
+	return ok
+}
+
+// remove removes the job. One-time jobs call it when they are done,
+// as gocron keeps them after their only run.
+func (registry *JobRegistry) remove(key JobKey) {
+	if scheduleEngine == ScheduleEngineDispatcher {
+		resetNextFires(key.ChatId, key.Kind)
+		return
+	}
+	if !schedulingLeader.isLeader() {
+		notifySchedulesChanged(key.ChatId)
+		return
+	}
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	if jobId, ok := registry.jobs[key]; ok {
+		scheduler.RemoveJob(jobId)
+		delete(registry.jobs, key)
+	}
+}
+
+// removeSchedule removes all jobs of one schedule of the chat.
+func (registry *JobRegistry) removeSchedule(chatId int64, kind JobKind, schedule string) {
+	if scheduleEngine == ScheduleEngineDispatcher {
+		resetNextFires(chatId, kind)
+		return
+	}
+	if !schedulingLeader.isLeader() {
+		notifySchedulesChanged(chatId)
+		return
+	}
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	for key := range registry.jobs {
+		if key.ChatId == chatId && key.Kind == kind && key.Schedule == schedule {
+			delete(registry.jobs, key)
+		}
+	}
+	scheduler.RemoveByTags(scheduleJobsTag(chatId, kind, schedule))
+}
+
+// removeChat removes all jobs of the kind for the chat.
+func (registry *JobRegistry) removeChat(chatId int64, kind JobKind) {
+	if scheduleEngine == ScheduleEngineDispatcher {
+		resetNextFires(chatId, kind)
+		return
+	}
+	if !schedulingLeader.isLeader() {
+		notifySchedulesChanged(chatId)
+		return
+	}
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	for key := range registry.jobs {
+		if key.ChatId == chatId && key.Kind == kind {
+			delete(registry.jobs, key)
+		}
+	}
+	scheduler.RemoveByTags(chatJobsTag(chatId, kind))
+}
+
+// removeAll removes the jobs of all chats, when the instance stops being the leader.
+func (registry *JobRegistry) removeAll() {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.jobs = make(map[JobKey]uuid.UUID)
+	scheduler.RemoveByTags(scheduleJobsTagAll)
+}
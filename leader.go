@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/lib/pq"
+)
+
+// schedulingLockKey is the id of the Postgres advisory lock held by the scheduling leader.
+const schedulingLockKey = 1073741827
+
+const leaderCheckInterval = 10 * time.Second
+const schedulesChangedChannel = "schedules_changed"
+const schedulesReloadDelay = time.Second
+const maintenanceJobsTag = "maintenance"
+
+var errNotSchedulingLeader = errors.New("not the scheduling leader")
+
+// SchedulingLeader holds the advisory lock on a dedicated connection. Only the instance
+// holding it runs schedules, while every instance serves webhooks. The lock belongs to
+// the session, so if the leader dies another instance takes over within leaderCheckInterval.
+type SchedulingLeader struct {
+	mutex sync.Mutex
+	conn  *sql.Conn
+	stop  chan struct{}
+}
+
+var schedulingLeader = &SchedulingLeader{stop: make(chan struct{})}
+
+// isLeader is always true with the memory storage, which serves a single instance.
+func (leader *SchedulingLeader) isLeader() bool {
+	if storageBackend == StorageBackendMemory {
+		return true
+	}
+	leader.mutex.Lock()
+	defer leader.mutex.Unlock()
+	return leader.conn != nil
+}
+
+// IsLeader is the gocron elector: every job run first checks that the session holding
+// the lock is alive, so jobs stop firing as soon as it is lost instead of at the next
+// check, when another instance may already have taken the lock.
+func (leader *SchedulingLeader) IsLeader(ctx context.Context) error {
+	if storageBackend == StorageBackendMemory {
+		return nil
+	}
+	leader.mutex.Lock()
+	defer leader.mutex.Unlock()
+	if leader.conn == nil {
+		return errNotSchedulingLeader
+	}
+	err := leader.conn.PingContext(ctx)
+	if err != nil {
+		println("scheduling leader connection lost", err.Error())
+		return err
+	}
+	return nil
+}
+
+// check makes sure the session holding the lock is still alive, or tries to take the lock.
+func (leader *SchedulingLeader) check() bool {
+	leader.mutex.Lock()
+	defer leader.mutex.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), leaderCheckInterval)
+	defer cancel()
+	if leader.conn != nil {
+		_, err := leader.conn.ExecContext(ctx, "select 1;")
+		if err == nil {
+			return true
+		}
+		println("scheduling leader connection lost", err.Error())
+		leader.conn.Close()
+		leader.conn = nil
+	}
+	conn, err := database.Conn(ctx)
+	if err != nil {
+		println(err.Error())
+		return false
+	}
+	var locked bool
+	err = conn.QueryRowContext(ctx, "select pg_try_advisory_lock($1);", schedulingLockKey).Scan(&locked)
+	if err != nil || !locked {
+		conn.Close()
+		return false
+	}
+	leader.conn = conn
+	return true
+}
+
+func (leader *SchedulingLeader) release() {
+	leader.mutex.Lock()
+	defer leader.mutex.Unlock()
+	if leader.conn == nil {
+		return
+	}
+	_, err := leader.conn.ExecContext(context.Background(), "select pg_advisory_unlock($1);", schedulingLockKey)
+	if err != nil {
+		println("error releasing scheduling lock", err.Error())
+	}
+	leader.conn.Close()
+	leader.conn = nil
+}
+
+// startLeaderElection runs the first election synchronously, so a single instance
+// has its schedules loaded before it starts serving webhooks.
+func startLeaderElection() error {
+	if storageBackend == StorageBackendMemory {
+		return startScheduling()
+	}
+	if schedulingLeader.check() {
+		println("became the scheduling leader")
+		err := startScheduling()
+		if err != nil {
+			return err
+		}
+	}
+	go listenSchedulesChanges()
+	go func() {
+		ticker := time.NewTicker(leaderCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-schedulingLeader.stop:
+				return
+			case <-ticker.C:
+			}
+			wasLeader := schedulingLeader.isLeader()
+			isLeader := schedulingLeader.check()
+			if isLeader && !wasLeader {
+				println("became the scheduling leader")
+				err := startScheduling()
+				if err != nil {
+					sendErrorReport(err, "error starting scheduling")
+					println(err.Error())
+				}
+			} else if !isLeader && wasLeader {
+				println("lost the scheduling leadership")
+				stopScheduling()
+			}
+		}
+	}()
+	return nil
+}
+
+func stopLeaderElection() {
+	close(schedulingLeader.stop)
+	schedulingLeader.release()
+}
+
+// startScheduling loads all schedules from the database and starts the daily maintenance.
+// With the dispatcher engine schedules are not loaded, only random time sends are planned.
+func startScheduling() error {
+	if scheduleEngine == ScheduleEngineJobs {
+		err := loadScheduleJobs()
+		if err != nil {
+			return err
+		}
+	}
+	err := setDailyRandomTimeTasks()
+	if err != nil {
+		return err
+	}
+	_, err = scheduler.NewJob(gocron.CronJob("0 1 * * *", false), gocron.NewTask(func() {
+		setDailyRandomTimeTasks()
+		if scheduleEngine == ScheduleEngineJobs {
+			setDailyCronGapFires()
+			setDailySolarTasks()
+			storage.ClearOldSends()
+		}
+		storage.ClearOldProcessedUpdates()
+		storage.ClearExpiredPauses()
+		removeExpiredSchedules()
+		storage.ClearOldReminders()
+	}), gocron.WithTags(maintenanceJobsTag))
+	return err
+}
+
+func loadScheduleJobs() error {
+	err := setCronJobs()
+	if err != nil {
+		return err
+	}
+	err = catchUpMissedSends()
+	if err != nil {
+		return err
+	}
+	err = storage.ClearOldSends()
+	if err != nil {
+		return err
+	}
+	err = createRandomTimeJobsAfterRestart()
+	if err != nil {
+		return err
+	}
+	err = createReminderJobsAfterRestart()
+	if err != nil {
+		return err
+	}
+	return setDailySolarTasks()
+}
+
+func stopScheduling() {
+	jobRegistry.removeAll()
+	scheduler.RemoveByTags(maintenanceJobsTag)
+}
+
+// notifySchedulesChanged asks the leader to reload the jobs of the chat.
+func notifySchedulesChanged(chatId int64) {
+	_, err := database.Exec("select pg_notify($1, $2);", schedulesChangedChannel, strconv.FormatInt(chatId, 10))
+	if err != nil {
+		handleDbError(err)
+	}
+}
+
+// listenSchedulesChanges reloads the jobs of chats whose schedules were changed on other
+// instances. Notifications coming in a burst are handled together. After the listener
+// reconnects, notifications may have been lost, so the jobs of all chats are reloaded.
+func listenSchedulesChanges() {
+	listener := pq.NewListener(connStr, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			println("schedules listener", err.Error())
+		}
+	})
+	err := listener.Listen(schedulesChangedChannel)
+	if err != nil {
+		sendErrorReport(err, "error listening to schedules changes")
+		println(err.Error())
+		return
+	}
+	defer listener.Close()
+	pending := make(map[int64]bool)
+	reloadAll := false
+	var reload <-chan time.Time
+	for {
+		select {
+		case <-schedulingLeader.stop:
+			return
+		case notification := <-listener.Notify:
+			if notification == nil {
+				reloadAll = true
+			} else {
+				chatId, err := strconv.ParseInt(notification.Extra, 10, 64)
+				if err != nil {
+					continue
+				}
+				pending[chatId] = true
+			}
+			if reload == nil {
+				reload = time.After(schedulesReloadDelay)
+			}
+		case <-reload:
+			if schedulingLeader.isLeader() {
+				if reloadAll {
+					chats, err := storage.GetAllChats()
+					if err != nil {
+						println(err.Error())
+						reload = time.After(schedulesReloadDelay)
+						continue
+					}
+					for _, chatId := range chats {
+						pending[chatId] = true
+					}
+				}
+				for chatId := range pending {
+					err := reloadJobsForChat(chatId)
+					if err != nil {
+						sendErrorReport(err, "error reloading chat jobs")
+						println(err.Error())
+					}
+				}
+			}
+			pending = make(map[int64]bool)
+			reloadAll = false
+			reload = nil
+		}
+	}
+}
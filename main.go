@@ -1,26 +1,28 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"slices"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/go-co-op/gocron/v2"
+	"github.com/jonboulle/clockwork"
 )
 
-var scheduler gocron.Scheduler
+var scheduler Scheduler
 
 func main() {
 	getBibleFromFile()
-	getVersesListsFromFile()
-	println(getRandomVerseFromList(1))
 	createWebhook()
 	getAdminId()
 
@@ -40,32 +42,38 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
-	scheduler, err = gocron.NewScheduler(gocron.WithLocation(location))
+	scheduler, err = newScheduler(clockwork.NewRealClock(), gocron.WithLocation(location), gocron.WithStopTimeout(getShutdownTimeout()),
+		gocron.WithDistributedElector(schedulingLeader))
 	if err != nil {
 		panic(err)
 	}
 
+	startMessageSenders()
 	scheduler.Start()
-	defer func() { scheduler.Shutdown() }()
 
-	err = connectToDb()
+	err = openStorage()
 	if err != nil { panic(err) }
+	println(getRandomVerseFromList(1, defaultLanguage))
 	readTimezonesDiffsFile()
-	err = setCronJobs()
+	err = startLeaderElection()
 	if err != nil { panic(err) }
-	err = dbClearOldSends()
-	if err != nil { panic(err) }
-	err = createRandomTimeJobsAfterRestart()
-	if err != nil { panic(err) }
-	err = setDailyRandomTimeTasks()
-	if err != nil { panic(err) }
-	scheduler.NewJob(gocron.CronJob("0 1 * * *", false), gocron.NewTask(func() {
-		setDailyRandomTimeTasks()
-		dbClearOldSends()
-	}))
+	if scheduleEngine == ScheduleEngineDispatcher {
+		err = startDispatcher()
+		if err != nil { panic(err) }
+	}
 
+	startUpdateWorkers()
 	http.HandleFunc("/", func(writer http.ResponseWriter, request *http.Request) {
-		body, err := io.ReadAll(request.Body)
+		if request.Method != http.MethodPost {
+			writer.Header().Set("Allow", http.MethodPost)
+			http.Error(writer, "Method not allowed", 405)
+			return
+		}
+		if !checkWebhookSecretToken(request) {
+			http.Error(writer, "Forbidden", 403)
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(writer, request.Body, maxWebhookBodySize))
 		if err != nil {
 			http.Error(writer, "Error reading body", 400)
 			println(err.Error())
@@ -78,573 +86,1450 @@ func main() {
 			println(err.Error())
 			return
 		}
-		if update.CallbackQuery != nil {
-			chatId := update.CallbackQuery.Message.Chat.Id
+		claimed, err := storage.ClaimUpdate(update.UpdateId)
+		if err == nil && !claimed {
 			writer.WriteHeader(200)
-			if update.CallbackQuery.Data == "addcron cron" {
-				dbUpdateMessageStatus(chatId, MessageStatusAddCronCron)
-				message := SendMessage{
+			return
+		}
+		if !enqueueUpdate(update) {
+			// Telegram delivers the update again later.
+			storage.ReleaseUpdate(update.UpdateId)
+			http.Error(writer, "Too busy", 503)
+			return
+		}
+		writer.WriteHeader(200)
+	})
+	port := os.Getenv("LOCAL_PORT")
+	server := &http.Server{Addr: ":" + port}
+	go func() {
+		err := server.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGTERM, os.Interrupt)
+	<-stop
+	gracefulShutdown(server)
+}
+
+func handleUpdate(update Update) {
+	if update.CallbackQuery != nil {
+		chatId := update.CallbackQuery.Message.Chat.Id
+		lang, _ := storage.GetLanguage(chatId)
+		if update.CallbackQuery.Data == "addcron cron" {
+			storage.UpdateMessageStatus(chatId, MessageStatusAddCronCron)
+			message := SendMessage{
+				ChatId:             chatId,
+				Text:               tr(lang, "prompt_cron"),
+				ParseMode:          "MarkdownV2",
+				LinkPreviewOptions: LinkPreviewOptions{true},
+			}
+			sendMessage(message)
+		} else if update.CallbackQuery.Data == "addcron sun" {
+			_, _, hasLocation, err := storage.GetChatCoordinates(chatId)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			if hasLocation {
+				storage.UpdateMessageStatus(chatId, MessageStatusAddSolar)
+				sendMessage(SendMessage{
+					ChatId:      chatId,
+					Text:        tr(lang, "prompt_solar"),
+					ReplyMarkup: solarKeyboard(lang),
+				})
+				return
+			}
+			storage.UpdateMessageStatus(chatId, MessageStatusAddSolarLocation)
+			message := SendMessage{
+				ChatId: chatId,
+				Text:   tr(lang, "prompt_solar_location"),
+			}
+			if update.CallbackQuery.Message.Chat.ChatType == ChatTypePrivate {
+				message.ReplyMarkup = ReplyKeyboardMarkup{[][]KeyboardButton{{{tr(lang, "button_share_location"), true}}}}
+			}
+			sendMessage(message)
+		} else if len(update.CallbackQuery.Data) > 9 && update.CallbackQuery.Data[:9] == "addsolar:" {
+			event, err := strconv.Atoi(update.CallbackQuery.Data[9:])
+			if err != nil {
+				println(err.Error())
+				return
+			}
+			messageStatus, err := storage.GetMessageStatus(chatId)
+			if err != nil || messageStatus != MessageStatusAddSolar {
+				return
+			}
+			finishAddingSolar(chatId, lang, SolarVerse{Event: SolarEvent(event)})
+		} else if len(update.CallbackQuery.Data) > 12 && update.CallbackQuery.Data[:12] == "removesolar:" {
+			id, err := strconv.Atoi(update.CallbackQuery.Data[12:])
+			if err != nil {
+				println(err.Error())
+				return
+			}
+			err = removeSolarSchedule(chatId, id)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			sendMessage(SendMessage{
+				ChatId: chatId,
+				Text:   tr(lang, "solar_removed"),
+			})
+		} else if update.CallbackQuery.Data == "addcron 1" {
+			storage.UpdateMessageStatus(chatId, MessageStatusAddCron1)
+			message := SendMessage{
+				ChatId:    chatId,
+				Text:      tr(lang, "prompt_time"),
+				ParseMode: "MarkdownV2",
+			}
+			sendMessage(message)
+		} else if update.CallbackQuery.Data == "addcron 2" {
+			storage.UpdateMessageStatus(chatId, MessageStatusAddCron2)
+			message := SendMessage{
+				ChatId:    chatId,
+				Text:      tr(lang, "prompt_times"),
+				ParseMode: "MarkdownV2",
+			}
+			sendMessage(message)
+		} else if update.CallbackQuery.Data == "addcron 3" {
+			storage.UpdateMessageStatus(chatId, MessageStatusAddCron3)
+			message := SendMessage{
+				ChatId:    chatId,
+				Text:      tr(lang, "prompt_weekday_time"),
+				ParseMode: "MarkdownV2",
+			}
+			sendMessage(message)
+		} else if update.CallbackQuery.Data == "addcron 4" {
+			storage.UpdateMessageStatus(chatId, MessageStatusAddCron4)
+			message := SendMessage{
+				ChatId:    chatId,
+				Text:      tr(lang, "prompt_weekday_times"),
+				ParseMode: "MarkdownV2",
+			}
+			sendMessage(message)
+		} else if update.CallbackQuery.Data == "addcron 5" {
+			storage.UpdateMessageStatus(chatId, MessageStatusAddCron5)
+			message := SendMessage{
+				ChatId:    chatId,
+				Text:      tr(lang, "prompt_random_window"),
+				ParseMode: "MarkdownV2",
+			}
+			sendMessage(message)
+		} else if len(update.CallbackQuery.Data) > 11 && update.CallbackQuery.Data[:11] == "removecron:" {
+			err := removeCronForChat(chatId, update.CallbackQuery.Data[11:])
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			message := SendMessage{
+				ChatId:      chatId,
+				Text:        tr(lang, "schedule_removed", strings.Trim(update.CallbackQuery.Data[11:], " ")),
+				ParseMode:   "MarkdownV2",
+				ReplyMarkup: ReplyKeyboardRemove,
+			}
+			sendMessage(message)
+		} else if len(update.CallbackQuery.Data) > 17 && update.CallbackQuery.Data[:17] == "removerandomtime:" {
+			id, err := strconv.Atoi(update.CallbackQuery.Data[17:])
+			if err != nil {
+				println(err.Error())
+				return
+			}
+			err = removeRandomTimeRegular(update.CallbackQuery.Message.Chat.Id, id)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			message := SendMessage{
+				ChatId:      chatId,
+				Text:        tr(lang, "random_time_removed"),
+				ReplyMarkup: ReplyKeyboardRemove,
+			}
+			sendMessage(message)
+		} else if len(update.CallbackQuery.Data) > 15 && update.CallbackQuery.Data[:15] == "removereminder:" {
+			id, err := strconv.Atoi(update.CallbackQuery.Data[15:])
+			if err != nil {
+				println(err.Error())
+				return
+			}
+			err = removeReminderForChat(chatId, id)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			sendMessage(SendMessage{
+				ChatId: chatId,
+				Text:   tr(lang, "reminder_removed"),
+			})
+		} else if len(update.CallbackQuery.Data) > 10 && update.CallbackQuery.Data[:10] == "datescron:" {
+			storage.UpdateMessageStatusAndPendingInput(chatId, MessageStatusSetDates, "cron:"+update.CallbackQuery.Data[10:])
+			sendMessage(SendMessage{
+				ChatId:    chatId,
+				Text:      tr(lang, "prompt_dates"),
+				ParseMode: "MarkdownV2",
+			})
+		} else if len(update.CallbackQuery.Data) > 12 && update.CallbackQuery.Data[:12] == "datesrandom:" {
+			storage.UpdateMessageStatusAndPendingInput(chatId, MessageStatusSetDates, "random:"+update.CallbackQuery.Data[12:])
+			sendMessage(SendMessage{
+				ChatId:    chatId,
+				Text:      tr(lang, "prompt_dates"),
+				ParseMode: "MarkdownV2",
+			})
+		} else if len(update.CallbackQuery.Data) > 11 && update.CallbackQuery.Data[:11] == "randomdays:" {
+			weekDays, err := strconv.Atoi(update.CallbackQuery.Data[11:])
+			if err != nil {
+				println(err.Error())
+				return
+			}
+			messageStatus, err := storage.GetMessageStatus(chatId)
+			if err != nil {
+				return
+			}
+			if messageStatus == MessageStatusAddCron5Days {
+				finishAddingRandomTime(chatId, lang, weekDays)
+			} else if messageStatus == MessageStatusEditRandomDays {
+				finishEditingRandomTime(chatId, lang, "", weekDays)
+			}
+		} else if len(update.CallbackQuery.Data) > 14 && update.CallbackQuery.Data[:14] == "confirmrandom:" {
+			weekDays, err := strconv.Atoi(update.CallbackQuery.Data[14:])
+			if err != nil {
+				println(err.Error())
+				return
+			}
+			messageStatus, err := storage.GetMessageStatus(chatId)
+			if err != nil || messageStatus != MessageStatusConfirmRandom {
+				return
+			}
+			finishAddingRandomTime(chatId, lang, weekDays)
+		} else if update.CallbackQuery.Data == "confirmcron" || update.CallbackQuery.Data == "cancelcron" {
+			messageStatus, err := storage.GetMessageStatus(chatId)
+			if err != nil || (messageStatus != MessageStatusConfirmCron && messageStatus != MessageStatusConfirmRandom) {
+				return
+			}
+			if update.CallbackQuery.Data == "cancelcron" {
+				storage.UpdateMessageStatusAndPendingInput(chatId, MessageStatusDefault, "")
+				sendMessage(SendMessage{
 					ChatId: chatId,
-					Text: "Введите строку в формате [cron](https://ru.wikipedia.org/wiki/Cron) \\(воскресенье \\- 0\\)\\. " +
-						"Можно разделить несколько расписаний с помощью точки с запятой\\. " +
-						"Например: `0 9 * * 6,0; 0 6-22/2 * * 1-5`\n",
-					ParseMode: "MarkdownV2",
-					LinkPreviewOptions: LinkPreviewOptions{true},
-				}
-				go sendMessage(message)
-			} else if update.CallbackQuery.Data == "addcron 1" {
-				dbUpdateMessageStatus(chatId, MessageStatusAddCron1)
+					Text:   tr(lang, "operation_cancelled"),
+				})
+				return
+			}
+			if messageStatus != MessageStatusConfirmCron {
+				return
+			}
+			pendingInput, err := storage.GetPendingInput(chatId)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			finishAddingCrons(chatId, lang, strings.Split(pendingInput, ";"))
+		} else if len(update.CallbackQuery.Data) > 9 && update.CallbackQuery.Data[:9] == "editcron:" {
+			cron := strings.Trim(update.CallbackQuery.Data[9:], " ")
+			crons, err := storage.GetAllCrons(chatId)
+			if err != nil || !slices.Contains(crons, cron) {
+				return
+			}
+			storage.UpdateMessageStatusAndPendingInput(chatId, MessageStatusEditCron, cron)
+			message := SendMessage{
+				ChatId:    chatId,
+				Text:      tr(lang, "prompt_edit_cron", escapingSymbols(cronToString(cron, lang))),
+				ParseMode: "MarkdownV2",
+			}
+			sendMessage(message)
+		} else if len(update.CallbackQuery.Data) > 15 && update.CallbackQuery.Data[:15] == "editrandomtime:" {
+			id, err := strconv.Atoi(update.CallbackQuery.Data[15:])
+			if err != nil {
+				println(err.Error())
+				return
+			}
+			randomTime, err := getRandomTimeForChat(chatId, id)
+			if err != nil {
+				return
+			}
+			message := SendMessage{
+				ChatId: chatId,
+				Text:   randomTimeToString(randomTime, lang),
+				ReplyMarkup: InlineKeyboardMarkup{[][]InlineKeyboardButton{
+					{{tr(lang, "button_edit_window"), "editrandomwindow:" + strconv.Itoa(id)}},
+					{{tr(lang, "button_edit_days"), "editrandomdays:" + strconv.Itoa(id)}},
+				}},
+			}
+			sendMessage(message)
+		} else if len(update.CallbackQuery.Data) > 17 && update.CallbackQuery.Data[:17] == "editrandomwindow:" {
+			storage.UpdateMessageStatusAndPendingInput(chatId, MessageStatusEditRandomWindow, update.CallbackQuery.Data[17:])
+			message := SendMessage{
+				ChatId:    chatId,
+				Text:      tr(lang, "prompt_random_window"),
+				ParseMode: "MarkdownV2",
+			}
+			sendMessage(message)
+		} else if len(update.CallbackQuery.Data) > 15 && update.CallbackQuery.Data[:15] == "editrandomdays:" {
+			storage.UpdateMessageStatusAndPendingInput(chatId, MessageStatusEditRandomDays, update.CallbackQuery.Data[15:])
+			message := SendMessage{
+				ChatId:      chatId,
+				Text:        tr(lang, "prompt_random_window_days"),
+				ParseMode:   "MarkdownV2",
+				ReplyMarkup: randomDaysKeyboard(lang),
+			}
+			sendMessage(message)
+		} else if len(update.CallbackQuery.Data) > 9 && update.CallbackQuery.Data[:9] == "language:" {
+			newLang, ok := parseLanguage(update.CallbackQuery.Data[9:])
+			if !ok {
+				return
+			}
+			err := storage.UpdateLanguage(chatId, newLang)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			message := SendMessage{
+				ChatId:      chatId,
+				Text:        tr(newLang, "language_set"),
+				ReplyMarkup: ReplyKeyboardRemove,
+			}
+			sendMessage(message)
+		} else if len(update.CallbackQuery.Data) > 9 && update.CallbackQuery.Data[:9] == "calendar:" {
+			tradition, ok := parseCalendarTradition(update.CallbackQuery.Data[9:])
+			if !ok {
+				return
+			}
+			err := storage.UpdateCalendarTradition(chatId, tradition)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			sendMessage(SendMessage{
+				ChatId: chatId,
+				Text:   tr(lang, "calendar_set", tr(lang, "calendar_"+string(tradition))),
+			})
+		} else if len(update.CallbackQuery.Data) > 7 && update.CallbackQuery.Data[:7] == "source:" {
+			source := VerseSource(update.CallbackQuery.Data[7:])
+			if source != VerseSourceRandom && source != VerseSourceCalendar {
+				return
+			}
+			err := storage.UpdateVerseSource(chatId, source)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			sendMessage(SendMessage{
+				ChatId: chatId,
+				Text:   tr(lang, "verse_source_set", tr(lang, "verse_source_"+string(source))),
+			})
+		}
+		return
+	} else if update.Message != nil {
+		chatId := update.Message.Chat.Id
+		err := storage.AddChat(chatId, update.Message.Chat.ChatType, languageFromCode(update.Message.From.LanguageCode))
+		if err != nil {
+			sendErrorMessage(chatId, languageFromCode(update.Message.From.LanguageCode))
+			return
+		}
+		lang, _ := storage.GetLanguage(chatId)
+
+		statsDay := time.Now().In(statsLocation).Format(time.DateOnly)
+		storage.StatPlusOne(statsDay, "msg_received")
+		storage.StatUpdateChatsList(statsDay, "chats_received", chatId)
+
+		if update.Message.Text == "/cancel" || update.Message.Text == "/cancel@"+BotName {
+			messageStatus, _ := storage.GetMessageStatus(chatId)
+			if messageStatus != MessageStatusDefault {
+				storage.UpdateMessageStatus(chatId, MessageStatusDefault)
 				message := SendMessage{
-					ChatId:    chatId,
-					Text:      "Введите время в формате `чч:мм`\\. Например: `18:03`, или `07:40`",
-					ParseMode: "MarkdownV2",
+					ChatId:      chatId,
+					Text:        tr(lang, "operation_cancelled"),
+					ReplyMarkup: ReplyKeyboardRemove,
 				}
-				go sendMessage(message)
-			} else if update.CallbackQuery.Data == "addcron 2" {
-				dbUpdateMessageStatus(chatId, MessageStatusAddCron2)
-				message := SendMessage{
-					ChatId: chatId,
-					Text: "Введите время в формате `чч:мм`\\. Можно разделить несколько расписаний с помощью запятой\\. " +
-						"Например: `18:03, 07:40`, или `01:00, 10:20, 23:59`",
-					ParseMode: "MarkdownV2",
+				sendMessage(message)
+			}
+			return
+		}
+		if update.Message.Text == "/addregular" || update.Message.Text == "/addregular@"+BotName {
+			storage.StatPlusOne(statsDay, "cmd_addregular")
+			storage.UpdateMessageStatus(chatId, MessageStatusAddNatural)
+			message := SendMessage{
+				ChatId:    chatId,
+				Text:      tr(lang, "prompt_natural"),
+				ParseMode: "MarkdownV2",
+				ReplyMarkup: InlineKeyboardMarkup{[][]InlineKeyboardButton{
+					{{tr(lang, "button_once_a_day"), "addcron 1"}, {tr(lang, "button_several_a_day"), "addcron 2"}},
+					{{tr(lang, "button_once_a_week"), "addcron 3"}, {tr(lang, "button_several_a_week"), "addcron 4"}},
+					{{tr(lang, "button_random_daily"), "addcron 5"}, {tr(lang, "button_solar"), "addcron sun"}},
+					{{tr(lang, "button_cron"), "addcron cron"}},
+				}},
+			}
+			sendMessage(message)
+			return
+		}
+		if update.Message.Text == "/getregular" || update.Message.Text == "/getregular@"+BotName {
+			storage.StatPlusOne(statsDay, "cmd_getregular")
+			crons, err := storage.GetAllCrons(chatId)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			randomTimes, err := storage.GetAllRandomTimes(chatId)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			cronsDates, err := storage.GetCronsDates(chatId)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			reminders, err := storage.GetAllReminders(chatId)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			text := tr(lang, "current_schedules")
+			for _, cron := range crons {
+				text += "\n" + withDates(cronToString(cron, lang), cronsDates[cron], lang)
+			}
+			for _, rt := range randomTimes {
+				text += "\n" + withDates(randomTimeToString(rt, lang), rt.Dates, lang)
+			}
+			for _, reminder := range reminders {
+				text += "\n" + reminderToString(reminder, getChatLocation(chatId), lang)
+			}
+			solars, err := storage.GetAllSolarSchedules(chatId)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			for _, solar := range solars {
+				text += "\n" + solarToString(solar, lang)
+			}
+			if len(crons)+len(randomTimes)+len(reminders)+len(solars) == 0 {
+				text = tr(lang, "no_schedules")
+			} else {
+				paused, pausedUntil, err := storage.GetPause(chatId)
+				if err == nil && paused {
+					text += "\n\n" + pauseToString(paused, pausedUntil, getChatLocation(chatId), lang)
+				}
+			}
+			message := SendMessage{
+				ChatId: chatId,
+				Text:   text,
+			}
+			sendMessage(message)
+			return
+		}
+		if update.Message.Text == "/getregularcron" || update.Message.Text == "/getregularcron@"+BotName {
+			storage.StatPlusOne(statsDay, "cmd_getregularcron")
+			crons, err := storage.GetAllCrons(chatId)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			randomTimes, err := storage.GetAllRandomTimes(chatId)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			text := tr(lang, "current_schedules") + "\n`"
+			for i, cron := range crons {
+				if i > 0 {
+					text += "; "
 				}
-				go sendMessage(message)
-			} else if update.CallbackQuery.Data == "addcron 3" {
-				dbUpdateMessageStatus(chatId, MessageStatusAddCron3)
+				text += cron
+			}
+			text += "`"
+			for _, rt := range randomTimes {
+				text += "\n" + escapingSymbols(randomTimeToString(rt, lang))
+			}
+			if len(crons)+len(randomTimes) == 0 {
+				text = tr(lang, "no_schedules")
+			}
+			message := SendMessage{
+				ChatId:    chatId,
+				Text:      text,
+				ParseMode: "MarkdownV2",
+			}
+			sendMessage(message)
+			return
+		}
+		if update.Message.Text == "/removeregular" || update.Message.Text == "/removeregular@"+BotName {
+			storage.StatPlusOne(statsDay, "cmd_removeregular")
+			crons, err := storage.GetAllCrons(chatId)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			randomTimes, err := storage.GetAllRandomTimes(chatId)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			reminders, err := storage.GetAllReminders(chatId)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			solars, err := storage.GetAllSolarSchedules(chatId)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			if len(crons)+len(randomTimes)+len(reminders)+len(solars) == 0 {
 				message := SendMessage{
 					ChatId: chatId,
-					Text: "Введите номер дня недели и время в формате `д чч:мм`\\. Например: `1 18:03`, или `7 07:40`\\. " +
-						"\\(1 \\- понедельник, 7 \\- воскресенье\\)",
-					ParseMode: "MarkdownV2",
+					Text:   tr(lang, "no_schedules"),
 				}
-				go sendMessage(message)
-			} else if update.CallbackQuery.Data == "addcron 4" {
-				dbUpdateMessageStatus(chatId, MessageStatusAddCron4)
+				sendMessage(message)
+				return
+			}
+			replyMarkup := InlineKeyboardMarkup{[][]InlineKeyboardButton{}}
+			for _, cron := range crons {
+				replyMarkup.InlineKeyboard = append(replyMarkup.InlineKeyboard,
+					[]InlineKeyboardButton{{cronToString(cron, lang), "removecron:" + cron}})
+			}
+			for _, randomTime := range randomTimes {
+				replyMarkup.InlineKeyboard = append(replyMarkup.InlineKeyboard,
+					[]InlineKeyboardButton{{randomTimeToShortString(randomTime, lang), "removerandomtime:" + strconv.Itoa(randomTime.Id)}})
+			}
+			for _, reminder := range reminders {
+				replyMarkup.InlineKeyboard = append(replyMarkup.InlineKeyboard,
+					[]InlineKeyboardButton{{reminderToString(reminder, getChatLocation(chatId), lang), "removereminder:" + strconv.Itoa(reminder.Id)}})
+			}
+			for _, solar := range solars {
+				replyMarkup.InlineKeyboard = append(replyMarkup.InlineKeyboard,
+					[]InlineKeyboardButton{{solarToString(solar, lang), "removesolar:" + strconv.Itoa(solar.Id)}})
+			}
+			message := SendMessage{
+				ChatId:      chatId,
+				Text:        tr(lang, "choose_schedule_to_remove"),
+				ReplyMarkup: replyMarkup,
+			}
+			sendMessage(message)
+			return
+		}
+		if update.Message.Text == "/editregular" || update.Message.Text == "/editregular@"+BotName {
+			storage.StatPlusOne(statsDay, "cmd_editregular")
+			crons, err := storage.GetAllCrons(chatId)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			randomTimes, err := storage.GetAllRandomTimes(chatId)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			if len(crons)+len(randomTimes) == 0 {
 				message := SendMessage{
 					ChatId: chatId,
-					Text: "Введите номер дня недели и время в формате `д чч:мм`\\. Можно разделить несколько расписаний с помощью запятой\\. " +
-						"Например: `1 18:03, 7 07:40`\\. \\(1 \\- понедельник, 7 \\- воскресенье\\)",
-					ParseMode: "MarkdownV2",
+					Text:   tr(lang, "no_schedules"),
 				}
-				go sendMessage(message)
-			} else if update.CallbackQuery.Data == "addcron 5" {
-				dbUpdateMessageStatus(chatId, MessageStatusAddCron5)
-				message := SendMessage{
+				sendMessage(message)
+				return
+			}
+			replyMarkup := InlineKeyboardMarkup{[][]InlineKeyboardButton{}}
+			for _, cron := range crons {
+				replyMarkup.InlineKeyboard = append(replyMarkup.InlineKeyboard,
+					[]InlineKeyboardButton{{cronToString(cron, lang), "editcron:" + cron}})
+			}
+			for _, randomTime := range randomTimes {
+				replyMarkup.InlineKeyboard = append(replyMarkup.InlineKeyboard,
+					[]InlineKeyboardButton{{randomTimeToShortString(randomTime, lang), "editrandomtime:" + strconv.Itoa(randomTime.Id)}})
+			}
+			message := SendMessage{
+				ChatId:      chatId,
+				Text:        tr(lang, "choose_schedule_to_edit"),
+				ReplyMarkup: replyMarkup,
+			}
+			sendMessage(message)
+			return
+		}
+		if (len(update.Message.Text) > 7 && update.Message.Text[:8] == "/remind ") || update.Message.Text == "/remind" ||
+			(len(update.Message.Text) >= 8+len(BotName) && update.Message.Text[:8+len(BotName)] == "/remind@"+BotName) {
+			storage.StatPlusOne(statsDay, "cmd_remind")
+			arg := ""
+			if i := strings.Index(update.Message.Text, " "); i >= 0 {
+				arg = update.Message.Text[i+1:]
+			}
+			loc := getChatLocation(chatId)
+			sendAt, err := parseReminderTime(arg, loc, scheduler.Now())
+			if err != nil {
+				sendMessage(SendMessage{
+					ChatId:    chatId,
+					Text:      tr(lang, "prompt_remind"),
+					ParseMode: "MarkdownV2",
+				})
+				return
+			}
+			err = addReminder(chatId, sendAt)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			sendMessage(SendMessage{
+				ChatId: chatId,
+				Text:   tr(lang, "reminder_added", sendAt.In(loc).Format("2006-01-02 15:04")),
+			})
+			return
+		}
+		if update.Message.Text == "/forgetlocation" || update.Message.Text == "/forgetlocation@"+BotName {
+			storage.StatPlusOne(statsDay, "cmd_forgetlocation")
+			err := forgetChatLocation(chatId)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			sendMessage(SendMessage{
+				ChatId: chatId,
+				Text:   tr(lang, "location_forgotten"),
+			})
+			return
+		}
+		if update.Message.Text == "/setdates" || update.Message.Text == "/setdates@"+BotName {
+			storage.StatPlusOne(statsDay, "cmd_setdates")
+			crons, err := storage.GetAllCrons(chatId)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			randomTimes, err := storage.GetAllRandomTimes(chatId)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			if len(crons)+len(randomTimes) == 0 {
+				sendMessage(SendMessage{
 					ChatId: chatId,
-					Text: "Введите время начала и конца промежутка для отправки в случайное время " +
-						"в формате `чч:мм, чч:мм`\\. Например: `07:40, 18:03`\\.",
+					Text:   tr(lang, "no_schedules"),
+				})
+				return
+			}
+			replyMarkup := InlineKeyboardMarkup{[][]InlineKeyboardButton{}}
+			for _, cron := range crons {
+				replyMarkup.InlineKeyboard = append(replyMarkup.InlineKeyboard,
+					[]InlineKeyboardButton{{cronToString(cron, lang), "datescron:" + cron}})
+			}
+			for _, randomTime := range randomTimes {
+				replyMarkup.InlineKeyboard = append(replyMarkup.InlineKeyboard,
+					[]InlineKeyboardButton{{randomTimeToShortString(randomTime, lang), "datesrandom:" + strconv.Itoa(randomTime.Id)}})
+			}
+			sendMessage(SendMessage{
+				ChatId:      chatId,
+				Text:        tr(lang, "choose_schedule_for_dates"),
+				ReplyMarkup: replyMarkup,
+			})
+			return
+		}
+		if (len(update.Message.Text) > 6 && update.Message.Text[:7] == "/pause ") || update.Message.Text == "/pause" ||
+			(len(update.Message.Text) >= 7+len(BotName) && update.Message.Text[:7+len(BotName)] == "/pause@"+BotName) {
+			storage.StatPlusOne(statsDay, "cmd_pause")
+			arg := ""
+			if i := strings.Index(update.Message.Text, " "); i >= 0 {
+				arg = update.Message.Text[i+1:]
+			}
+			loc := getChatLocation(chatId)
+			pausedUntil, err := parsePauseEnd(arg, loc)
+			if err != nil {
+				sendMessage(SendMessage{
+					ChatId:    chatId,
+					Text:      tr(lang, "prompt_pause"),
 					ParseMode: "MarkdownV2",
-				}
-				go sendMessage(message)
-			} else if len(update.CallbackQuery.Data) > 11 && update.CallbackQuery.Data[:11] == "removecron:" {
-				err := removeCronForChat(chatId, update.CallbackQuery.Data[11:])
-				if err != nil {
-					sendErrorMessage(chatId)
-					return
-				}
-				message := SendMessage{
-					ChatId:      chatId,
-					Text:        "Расписание `" + strings.Trim(update.CallbackQuery.Data[11:], " ") + "` удалено",
-					ParseMode:   "MarkdownV2",
-					ReplyMarkup: ReplyKeyboardRemove,
-				}
-				go sendMessage(message)
-			} else if len(update.CallbackQuery.Data) > 17 && update.CallbackQuery.Data[:17] == "removerandomtime:" {
-				id, err := strconv.Atoi(update.CallbackQuery.Data[17:])
-				if err != nil {
-					println(err.Error())
-					return
-				}
-				err = removeRandomTimeRegular(update.CallbackQuery.Message.Chat.Id, id)
-				if err != nil {
-					sendErrorMessage(chatId)
-					return
-				}
-				message := SendMessage{
-					ChatId:      chatId,
-					Text:        "Расписание случайного времени отправки удалено",
-					ReplyMarkup: ReplyKeyboardRemove,
-				}
-				go sendMessage(message)
+				})
+				return
 			}
+			err = storage.UpdatePause(chatId, true, pausedUntil)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			sendMessage(SendMessage{
+				ChatId: chatId,
+				Text:   pauseToString(true, pausedUntil, loc, lang) + ". " + tr(lang, "resume_hint"),
+			})
 			return
-		} else if update.Message != nil {
-			chatId := update.Message.Chat.Id
-			err := dbAddChat(chatId, update.Message.Chat.ChatType)
+		}
+		if update.Message.Text == "/resume" || update.Message.Text == "/resume@"+BotName {
+			storage.StatPlusOne(statsDay, "cmd_resume")
+			err := storage.UpdatePause(chatId, false, sql.NullTime{})
 			if err != nil {
-				sendErrorMessage(chatId)
+				sendErrorMessage(chatId, lang)
 				return
 			}
-			writer.WriteHeader(200)
-
-			statsDay := time.Now().In(statsLocation).Format(time.DateOnly)
-			dbStatPlusOne(statsDay, "msg_received")
-			dbStatUpdateChatsList(statsDay, "chats_received", chatId)
-
-			if update.Message.Text == "/cancel" || update.Message.Text == "/cancel@"+BotName {
-				messageStatus, _ := dbGetMessageStatus(chatId)
-				if messageStatus != MessageStatusDefault {
-					dbUpdateMessageStatus(chatId, MessageStatusDefault)
-					message := SendMessage{
-						ChatId:      chatId,
-						Text:        "Операция отменена",
-						ReplyMarkup: ReplyKeyboardRemove,
-					}
-					go sendMessage(message)
+			err = planAllRandomTimesForChat(chatId)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			sendMessage(SendMessage{
+				ChatId: chatId,
+				Text:   tr(lang, "resumed"),
+			})
+			return
+		}
+		if update.Message.Text == "/nextsends" || update.Message.Text == "/nextsends@"+BotName {
+			storage.StatPlusOne(statsDay, "cmd_nextsends")
+			sends, err := getNextSends(chatId, 10)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			text := tr(lang, "no_next_sends")
+			if len(sends) > 0 {
+				text = tr(lang, "next_sends") + "\n" + fireTimesToString(sends, getChatLocation(chatId), lang)
+				paused, pausedUntil, err := storage.GetPause(chatId)
+				if err == nil && paused {
+					text += "\n\n" + pauseToString(paused, pausedUntil, getChatLocation(chatId), lang)
 				}
+			}
+			sendMessage(SendMessage{
+				ChatId: chatId,
+				Text:   text,
+			})
+			return
+		}
+		if update.Message.Text == "/clearregular" || update.Message.Text == "/clearregular@"+BotName {
+			storage.StatPlusOne(statsDay, "cmd_clearregular")
+			err := clearSchedulesForChat(chatId)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
 				return
 			}
-			if update.Message.Text == "/addregular" || update.Message.Text == "/addregular@"+BotName {
-				dbStatPlusOne(statsDay, "cmd_addregular")
+			message := SendMessage{
+				ChatId:    chatId,
+				Text:      tr(lang, "schedules_cleared"),
+				ParseMode: "MarkdownV2",
+			}
+			sendMessage(message)
+			return
+		}
+		if update.Message.Text == "/random" || update.Message.Text == "/random@"+BotName ||
+			update.Message.Text == "/verse" || update.Message.Text == "/verse@"+BotName ||
+			isTextForAnyLanguage(update.Message.Text, "random_verse_button") {
+			storage.StatPlusOne(statsDay, "cmd_random")
+			message := SendMessage{
+				ChatId: chatId,
+				Text:   bibleForLanguage(lang).getRandomVerse(),
+			}
+			sendMessage(message)
+			return
+		}
+		if update.Message.Text == "/settimezone" || update.Message.Text == "/settimezone@"+BotName {
+			storage.StatPlusOne(statsDay, "cmd_settimezone")
+			storage.UpdateMessageStatus(chatId, MessageStatusSetTimezone)
+			if update.Message.Chat.ChatType == ChatTypePrivate {
 				message := SendMessage{
 					ChatId: chatId,
-					Text:   "Выберите периодичность",
-					ReplyMarkup: InlineKeyboardMarkup{[][]InlineKeyboardButton{
-						{{"Раз в день", "addcron 1"}, {"Несколько раз в день", "addcron 2"}},
-						{{"Раз в неделю", "addcron 3"}, {"Несколько раз в неделю", "addcron 4"}},
-						{{"Случайно в промежутке, каждый день", "addcron 5"}},
-						{{"Задать строку cron", "addcron cron"}},
-					}},
+					Text:               tr(lang, "prompt_timezone_location"),
+					ParseMode:          "MarkdownV2",
+					ReplyMarkup:        chooseTimezoneKeyboard(lang),
+					LinkPreviewOptions: LinkPreviewOptions{true},
 				}
-				go sendMessage(message)
+				sendMessage(message)
 				return
-			}
-			if update.Message.Text == "/getregular" || update.Message.Text == "/getregular@"+BotName {
-				dbStatPlusOne(statsDay, "cmd_getregular")
-				crons, err := dbGetAllCrons(chatId)
-				if err != nil {
-					sendErrorMessage(chatId)
-					return
-				}
-				randomTimes, err := dbGetAllRandomTimes(chatId)
-				if err != nil {
-					sendErrorMessage(chatId)
-					return
-				}
-				text := "Текущие расписания:"
-				for _, cron := range crons {
-					text += "\n" + cronToString(cron)
-				}
-				for _, rt := range randomTimes {
-					text += "\n" + randomTimeToString(rt)
-				}
-				if len(crons)+len(randomTimes) == 0 {
-					text = "Нет регулярных расписаний"
+			} else {
+				message := SendMessage{
+					ChatId: chatId,
+					Text:               tr(lang, "prompt_timezone"),
+					ParseMode:          "MarkdownV2",
+					ReplyMarkup:        chooseTimezoneKeyboardNoLocation,
+					LinkPreviewOptions: LinkPreviewOptions{true},
 				}
+				sendMessage(message)
+				return
+			}
+		}
+		if update.Message.Text == "/gettimezone" || update.Message.Text == "/gettimezone@"+BotName {
+			storage.StatPlusOne(statsDay, "cmd_gettimezone")
+			timezone, err := storage.GetTimezone(chatId)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			message := SendMessage{
+				ChatId:    chatId,
+				Text:      tr(lang, "current_timezone", displayTimezone(timezone)),
+				ParseMode: "MarkdownV2",
+			}
+			sendMessage(message)
+			return
+		}
+		if update.Message.Text == "/broadcast" || update.Message.Text == "/broadcast@"+BotName {
+			if update.Message.From.Id == adminId {
+				storage.UpdateMessageStatus(chatId, MessageStatusBroadcast)
 				message := SendMessage{
-					ChatId: chatId,
-					Text:   text,
+					ChatId:      chatId,
+					Text:        "Отправьте сообщение для общей рассылки",
+					ReplyMarkup: ReplyKeyboardRemove,
 				}
-				go sendMessage(message)
+				sendMessage(message)
 				return
 			}
-			if update.Message.Text == "/getregularcron" || update.Message.Text == "/getregularcron@"+BotName {
-				dbStatPlusOne(statsDay, "cmd_getregularcron")
-				crons, err := dbGetAllCrons(chatId)
-				if err != nil {
-					sendErrorMessage(chatId)
-					return
+		}
+		if (len(update.Message.Text) > 6 && update.Message.Text[:7] == "/stats ") || (update.Message.Text == "/stats") ||
+			(len(update.Message.Text) > 6+len(BotName) && update.Message.Text[:7+len(BotName)] == "/stats@"+BotName) {
+			if update.Message.From.Id == adminId || update.Message.From.Id == developerId {
+				args := strings.Split(update.Message.Text, " ")
+				startDate := time.Now().In(statsLocation).Add(-7 * 24 * time.Hour).Format(time.DateOnly)
+				endDate := time.Now().In(statsLocation).Format(time.DateOnly)
+				if len(args) > 2 {
+					endDate = args[2]
+				}
+				if len(args) > 1 {
+					startDate = args[1]
 				}
-				randomTimes, err := dbGetAllRandomTimes(chatId)
+				text, err := getStatsMessageText(startDate, endDate, "none")
 				if err != nil {
-					sendErrorMessage(chatId)
+					sendErrorMessage(chatId, lang)
 					return
 				}
-				text := "Текущие расписания:\n`"
-				for i, cron := range crons {
-					if i > 0 {
-						text += "; "
-					}
-					text += cron
-				}
-				text += "`"
-				for _, rt := range randomTimes {
-					text += "\n" + randomTimeToString(rt)
+				sendMessage(SendMessage{
+					ChatId:      chatId,
+					Text:        text,
+					ParseMode:   "MarkdownV2",
+					ReplyMarkup: ReplyKeyboardRemove,
+				})
+				return
+			}
+		}
+		if (len(update.Message.Text) > 6 && update.Message.Text[:7] == "/statsw") ||
+			(len(update.Message.Text) > 7+len(BotName) && update.Message.Text[:8+len(BotName)] == "/statsw@"+BotName) {
+			if update.Message.From.Id == adminId || update.Message.From.Id == developerId {
+				args := strings.Split(update.Message.Text, " ")
+				startDate := time.Now().In(statsLocation).Add(-26 * 7 * 24 * time.Hour).Format(time.DateOnly)
+				endDate := time.Now().In(statsLocation).Format(time.DateOnly)
+				if len(args) > 2 {
+					endDate = args[2]
 				}
-				if len(crons)+len(randomTimes) == 0 {
-					text = "Нет регулярных расписаний"
+				if len(args) > 1 {
+					startDate = args[1]
 				}
-				message := SendMessage{
-					ChatId:    chatId,
-					Text:      text,
-					ParseMode: "MarkdownV2",
+				text, err := getStatsMessageText(startDate, endDate, "week")
+				if err != nil {
+					sendErrorMessage(chatId, lang)
+					return
 				}
-				go sendMessage(message)
+				sendMessage(SendMessage{
+					ChatId:      chatId,
+					Text:        text,
+					ParseMode:   "MarkdownV2",
+					ReplyMarkup: ReplyKeyboardRemove,
+				})
 				return
 			}
-			if update.Message.Text == "/removeregular" || update.Message.Text == "/removeregular@"+BotName {
-				dbStatPlusOne(statsDay, "cmd_removeregular")
-				crons, err := dbGetAllCrons(chatId)
+		}
+		if (len(update.Message.Text) > 6 && update.Message.Text[:7] == "/statsm") ||
+			(len(update.Message.Text) > 7+len(BotName) && update.Message.Text[:8+len(BotName)] == "/statsm@"+BotName) {
+			if update.Message.From.Id == adminId || update.Message.From.Id == developerId {
+				args := strings.Split(update.Message.Text, " ")
+				startDate := "2024-11-17"
+				endDate := time.Now().In(statsLocation).Format(time.DateOnly)
+				if len(args) > 2 {
+					endDate = args[2]
+				}
+				if len(args) > 1 {
+					startDate = args[1]
+				}
+				text, err := getStatsMessageText(startDate, endDate, "month")
 				if err != nil {
-					sendErrorMessage(chatId)
+					sendErrorMessage(chatId, lang)
 					return
 				}
-				randomTimes, err := dbGetAllRandomTimes(chatId)
+				sendMessage(SendMessage{
+					ChatId:      chatId,
+					Text:        text,
+					ParseMode:   "MarkdownV2",
+					ReplyMarkup: ReplyKeyboardRemove,
+				})
+				return
+			}
+		}
+		if update.Message.Text == "/feast" || update.Message.Text == "/feast@"+BotName {
+			storage.StatPlusOne(statsDay, "cmd_feast")
+			sendMessage(SendMessage{
+				ChatId: chatId,
+				Text:   getFeastMessage(chatId, lang),
+			})
+			return
+		}
+		if update.Message.Text == "/calendar" || update.Message.Text == "/calendar@"+BotName {
+			storage.StatPlusOne(statsDay, "cmd_calendar")
+			sendMessage(SendMessage{
+				ChatId:      chatId,
+				Text:        tr(lang, "choose_calendar", tr(lang, "calendar_"+string(getChatCalendarTradition(chatId, lang)))),
+				ReplyMarkup: calendarKeyboard(lang),
+			})
+			return
+		}
+		if update.Message.Text == "/source" || update.Message.Text == "/source@"+BotName {
+			storage.StatPlusOne(statsDay, "cmd_source")
+			source, err := storage.GetVerseSource(chatId)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			sendMessage(SendMessage{
+				ChatId:      chatId,
+				Text:        tr(lang, "choose_verse_source", tr(lang, "verse_source_"+string(source))),
+				ReplyMarkup: verseSourceKeyboard(lang),
+			})
+			return
+		}
+		if update.Message.Text == "/language" || update.Message.Text == "/language@"+BotName {
+			storage.StatPlusOne(statsDay, "cmd_language")
+			message := SendMessage{
+				ChatId:      chatId,
+				Text:        tr(lang, "choose_language"),
+				ReplyMarkup: languageKeyboard,
+			}
+			sendMessage(message)
+			return
+		}
+		if update.Message.Text == "/start" || update.Message.Text == "/start@"+BotName {
+			storage.StatPlusOne(statsDay, "cmd_start")
+			message := getStartMessage(chatId, lang)
+			sendMessage(message)
+			storage.UpdateMessageStatus(chatId, MessageStatusSetTimezone)
+			return
+		}
+		messageStatus, err := storage.GetMessageStatus(chatId)
+		if err != nil {
+			sendErrorMessage(chatId, lang)
+		}
+		if messageStatus == MessageStatusAddNatural {
+			if update.Message.Text != "" {
+				schedule, err := parseNaturalSchedule(update.Message.Text)
 				if err != nil {
-					sendErrorMessage(chatId)
+					text := tr(lang, "natural_not_understood")
+					if errors.Is(err, errCronTooFrequent) {
+						text = trn(lang, "cron_too_frequent", int(minCronInterval/time.Minute))
+					}
+					if errors.Is(err, errRandomWindowTooShort) {
+						text = trn(lang, "random_window_too_short", minRandomSendsSpacing)
+					}
+					message := SendMessage{
+						ChatId: chatId,
+						Text:   text,
+					}
+					sendMessage(message)
 					return
 				}
-				if len(crons)+len(randomTimes) == 0 {
+				if schedule.RandomTime != nil {
+					randomTime := *schedule.RandomTime
+					endTime := (randomTime.StartTime + randomTime.Duration) % (24 * 60)
+					storage.UpdateMessageStatusAndPendingInput(chatId, MessageStatusConfirmRandom,
+						timeToString(randomTime.StartTime)+", "+timeToString(endTime)+", "+strconv.Itoa(randomTime.Count))
 					message := SendMessage{
 						ChatId: chatId,
-						Text:   "Нет регулярных расписаний",
+						Text:   tr(lang, "natural_understood") + "\n" + randomTimeToString(randomTime, lang),
+						ReplyMarkup: InlineKeyboardMarkup{[][]InlineKeyboardButton{
+							{{tr(lang, "button_confirm"), "confirmrandom:" + strconv.Itoa(randomTime.WeekDays)},
+								{tr(lang, "button_cancel"), "cancelcron"}},
+						}},
 					}
-					go sendMessage(message)
+					sendMessage(message)
 					return
 				}
-				replyMarkup := InlineKeyboardMarkup{[][]InlineKeyboardButton{}}
-				for _, cron := range crons {
-					replyMarkup.InlineKeyboard = append(replyMarkup.InlineKeyboard,
-						[]InlineKeyboardButton{{cronToString(cron), "removecron:" + cron}})
-				}
-				for _, randomTime := range randomTimes {
-					replyMarkup.InlineKeyboard = append(replyMarkup.InlineKeyboard,
-						[]InlineKeyboardButton{{randomTimeToShortString(randomTime), "removerandomtime:" + strconv.Itoa(randomTime.Id)}})
-				}
-				message := SendMessage{
-					ChatId:      chatId,
-					Text:        "Выберите расписание для удаления",
-					ReplyMarkup: replyMarkup,
-				}
-				go sendMessage(message)
-				return
-			}
-			if update.Message.Text == "/clearregular" || update.Message.Text == "/clearregular@"+BotName {
-				dbStatPlusOne(statsDay, "cmd_clearregular")
-				clearCronsForChat(chatId, false)
-				clearRandomTimesForChat(chatId)
-				message := SendMessage{
-					ChatId:    chatId,
-					Text:      "Расписания очищены",
-					ParseMode: "MarkdownV2",
+				descriptions := []string{}
+				for _, cron := range schedule.Crons {
+					descriptions = append(descriptions, cronToString(cron, lang))
 				}
-				go sendMessage(message)
-				return
-			}
-			if update.Message.Text == "/random" || update.Message.Text == "/random@"+BotName ||
-				update.Message.Text == "/verse" || update.Message.Text == "/verse@"+BotName ||
-				update.Message.Text == randomVerseTextMessage {
-				dbStatPlusOne(statsDay, "cmd_random")
+				storage.UpdateMessageStatusAndPendingInput(chatId, MessageStatusConfirmCron, strings.Join(schedule.Crons, ";"))
+				loc := getChatLocation(chatId)
 				message := SendMessage{
 					ChatId: chatId,
-					Text:   bible.getRandomVerse(),
+					Text: tr(lang, "natural_understood") + "\n" + strings.Join(descriptions, "\n") + "\n\n" +
+						tr(lang, "cron_preview") + "\n" + fireTimesToString(nextCronsFires(schedule.Crons, loc, scheduler.Now(), 5), loc, lang),
+					ReplyMarkup: InlineKeyboardMarkup{[][]InlineKeyboardButton{
+						{{tr(lang, "button_confirm"), "confirmcron"}, {tr(lang, "button_cancel"), "cancelcron"}},
+					}},
 				}
-				go sendMessage(message)
+				sendMessage(message)
 				return
 			}
-			if update.Message.Text == "/settimezone" || update.Message.Text == "/settimezone@"+BotName {
-				dbStatPlusOne(statsDay, "cmd_settimezone")
-				dbUpdateMessageStatus(chatId, MessageStatusSetTimezone)
-				if update.Message.Chat.ChatType == ChatTypePrivate {
+		}
+		if messageStatus >= 1 && messageStatus <= 5 {
+			if update.Message.Text != "" {
+				var crons []string
+				var err error = nil
+				if messageStatus == MessageStatusAddCronCron {
+					for _, cron := range strings.Split(update.Message.Text, ";") {
+						normalized, err := normalizeCron(cron)
+						if err != nil {
+							text := tr(lang, "invalid_format")
+							if errors.Is(err, errCronTooFrequent) {
+								text = trn(lang, "cron_too_frequent", int(minCronInterval/time.Minute))
+							}
+							message := SendMessage{
+								ChatId: chatId,
+								Text:   text,
+							}
+							sendMessage(message)
+							return
+						}
+						crons = append(crons, normalized)
+					}
+				} else if messageStatus == MessageStatusAddCron1 {
+					var cron string
+					cron, err = parseTimeToCron(update.Message.Text)
+					crons = []string{cron}
+				} else if messageStatus == MessageStatusAddCron2 {
+					crons, err = parseListTimesToCron(update.Message.Text)
+				} else if messageStatus == MessageStatusAddCron3 {
+					var cron string
+					cron, err = parseWeekDayTimeToCron(update.Message.Text)
+					crons = []string{cron}
+				} else if messageStatus == MessageStatusAddCron4 {
+					crons, err = parseListWeekDayTimesToCron(update.Message.Text)
+				}
+				if err != nil {
 					message := SendMessage{
 						ChatId: chatId,
-						Text: "Отправьте геопозицию, введите [название](https://en.wikipedia.org/wiki/List_of_tz_database_time_zones) часового пояса " +
-							"\\(Например: `Europe/Moscow`\\), или выберите разницу с UTC \\(Например: `UTC+1`\\)",
-						ParseMode:          "MarkdownV2",
-						ReplyMarkup:        chooseTimezoneKeyboard,
-						LinkPreviewOptions: LinkPreviewOptions{true},
+						Text:   tr(lang, "invalid_format"),
 					}
-					go sendMessage(message)
+					sendMessage(message)
 					return
-				} else {
+				}
+				if messageStatus == MessageStatusAddCronCron {
+					storage.UpdateMessageStatusAndPendingInput(chatId, MessageStatusConfirmCron, strings.Join(crons, ";"))
+					loc := getChatLocation(chatId)
 					message := SendMessage{
 						ChatId: chatId,
-						Text: "Введите [название](https://en.wikipedia.org/wiki/List_of_tz_database_time_zones) часового пояса " +
-							"\\(Например: `Europe/Moscow`\\), или выберите разницу с UTC \\(Например: `UTC+1`\\)",
-						ParseMode:          "MarkdownV2",
-						ReplyMarkup:        chooseTimezoneKeyboardNoLocation,
-						LinkPreviewOptions: LinkPreviewOptions{true},
+						Text:   tr(lang, "cron_preview") + "\n" + fireTimesToString(nextCronsFires(crons, loc, scheduler.Now(), 5), loc, lang),
+						ReplyMarkup: InlineKeyboardMarkup{[][]InlineKeyboardButton{
+							{{tr(lang, "button_confirm"), "confirmcron"}, {tr(lang, "button_cancel"), "cancelcron"}},
+						}},
 					}
-					go sendMessage(message)
+					sendMessage(message)
 					return
 				}
+				finishAddingCrons(chatId, lang, crons)
+				return
 			}
-			if update.Message.Text == "/gettimezone" || update.Message.Text == "/gettimezone@"+BotName {
-				dbStatPlusOne(statsDay, "cmd_gettimezone")
-				timezone, err := dbGetTimezone(chatId)
+		}
+		if messageStatus == MessageStatusAddCron5 {
+			if update.Message.Text != "" {
+				startTime, endTime, count, err := parseRandomTimeWindow(update.Message.Text)
 				if err != nil {
-					sendErrorMessage(chatId)
+					text := tr(lang, "invalid_format")
+					if errors.Is(err, errRandomWindowTooShort) {
+						text = trn(lang, "random_window_too_short", minRandomSendsSpacing)
+					}
+					message := SendMessage{
+						ChatId: chatId,
+						Text:   text,
+					}
+					sendMessage(message)
 					return
 				}
+				storage.UpdateMessageStatusAndPendingInput(chatId, MessageStatusAddCron5Days,
+					timeToString(startTime)+", "+timeToString(endTime)+", "+strconv.Itoa(count))
 				message := SendMessage{
 					ChatId:    chatId,
-					Text:      fmt.Sprintf("Текущий часовой пояс: `%s`", displayTimezone(timezone)),
+					Text:      tr(lang, "prompt_random_window_days"),
 					ParseMode: "MarkdownV2",
+					ReplyMarkup: randomDaysKeyboard(lang),
 				}
-				go sendMessage(message)
+				sendMessage(message)
 				return
 			}
-			if update.Message.Text == "/broadcast" || update.Message.Text == "/broadcast@"+BotName {
-				if update.Message.From.Id == adminId {
-					dbUpdateMessageStatus(chatId, MessageStatusBroadcast)
+		}
+		if messageStatus == MessageStatusAddCron5Days {
+			if update.Message.Text != "" {
+				weekDays, err := parseWeekDaysList(update.Message.Text)
+				if err != nil {
 					message := SendMessage{
-						ChatId:      chatId,
-						Text:        "Отправьте сообщение для общей рассылки",
-						ReplyMarkup: ReplyKeyboardRemove,
+						ChatId: chatId,
+						Text:   tr(lang, "invalid_format"),
 					}
-					go sendMessage(message)
+					sendMessage(message)
 					return
 				}
+				finishAddingRandomTime(chatId, lang, weekDays)
+				return
 			}
-			if (len(update.Message.Text) > 6 && update.Message.Text[:7] == "/stats ") || (update.Message.Text == "/stats") ||
-				(len(update.Message.Text) > 6+len(BotName) && update.Message.Text[:7+len(BotName)] == "/stats@"+BotName) {
-				if update.Message.From.Id == adminId || update.Message.From.Id == developerId {
-					args := strings.Split(update.Message.Text, " ")
-					startDate := time.Now().In(statsLocation).Add(-7 * 24 * time.Hour).Format(time.DateOnly)
-					endDate := time.Now().In(statsLocation).Format(time.DateOnly)
-					if len(args) > 2 {
-						endDate = args[2]
-					}
-					if len(args) > 1 {
-						startDate = args[1]
+		}
+		if messageStatus == MessageStatusEditCron {
+			if update.Message.Text != "" {
+				newCron, err := parseSingleScheduleToCron(update.Message.Text)
+				if err != nil {
+					text := tr(lang, "invalid_format")
+					if errors.Is(err, errCronTooFrequent) {
+						text = trn(lang, "cron_too_frequent", int(minCronInterval/time.Minute))
 					}
-					text, err := getStatsMessageText(startDate, endDate, "none")
-					if err != nil {
-						sendErrorMessage(chatId)
-						return
+					message := SendMessage{
+						ChatId: chatId,
+						Text:   text,
 					}
-					go sendMessage(SendMessage{
-						ChatId:      chatId,
-						Text:        text,
-						ParseMode:   "MarkdownV2",
-						ReplyMarkup: ReplyKeyboardRemove,
-					})
+					sendMessage(message)
 					return
 				}
-			}
-			if (len(update.Message.Text) > 6 && update.Message.Text[:7] == "/statsw") ||
-				(len(update.Message.Text) > 7+len(BotName) && update.Message.Text[:8+len(BotName)] == "/statsw@"+BotName) {
-				if update.Message.From.Id == adminId || update.Message.From.Id == developerId {
-					args := strings.Split(update.Message.Text, " ")
-					startDate := time.Now().In(statsLocation).Add(-26 * 7 * 24 * time.Hour).Format(time.DateOnly)
-					endDate := time.Now().In(statsLocation).Format(time.DateOnly)
-					if len(args) > 2 {
-						endDate = args[2]
-					}
-					if len(args) > 1 {
-						startDate = args[1]
-					}
-					text, err := getStatsMessageText(startDate, endDate, "week")
-					if err != nil {
-						sendErrorMessage(chatId)
-						return
+				oldCron, err := storage.GetPendingInput(chatId)
+				if err != nil {
+					sendErrorMessage(chatId, lang)
+					return
+				}
+				err = replaceCronForChat(chatId, oldCron, newCron)
+				if err != nil {
+					if errors.Is(err, errExistingCron) {
+						sendMessage(SendMessage{
+							ChatId: chatId,
+							Text:   tr(lang, "schedule_exists"),
+						})
+					} else {
+						if errors.Is(err, errCronNotFound) {
+							storage.UpdateMessageStatusAndPendingInput(chatId, MessageStatusDefault, "")
+						}
+						sendErrorMessage(chatId, lang)
 					}
-					go sendMessage(SendMessage{
-						ChatId:      chatId,
-						Text:        text,
-						ParseMode:   "MarkdownV2",
-						ReplyMarkup: ReplyKeyboardRemove,
-					})
 					return
 				}
+				storage.UpdateMessageStatusAndPendingInput(chatId, MessageStatusDefault, "")
+				message := SendMessage{
+					ChatId: chatId,
+					Text:   tr(lang, "schedule_changed", cronToString(newCron, lang)),
+				}
+				sendMessage(message)
+				return
 			}
-			if (len(update.Message.Text) > 6 && update.Message.Text[:7] == "/statsm") ||
-				(len(update.Message.Text) > 7+len(BotName) && update.Message.Text[:8+len(BotName)] == "/statsm@"+BotName) {
-				if update.Message.From.Id == adminId || update.Message.From.Id == developerId {
-					args := strings.Split(update.Message.Text, " ")
-					startDate := "2024-11-17"
-					endDate := time.Now().In(statsLocation).Format(time.DateOnly)
-					if len(args) > 2 {
-						endDate = args[2]
-					}
-					if len(args) > 1 {
-						startDate = args[1]
+		}
+		if messageStatus == MessageStatusEditRandomWindow || messageStatus == MessageStatusEditRandomDays {
+			if update.Message.Text != "" {
+				weekDays := 0
+				var err error
+				if messageStatus == MessageStatusEditRandomDays {
+					weekDays, err = parseWeekDaysList(update.Message.Text)
+				} else {
+					_, _, _, err = parseRandomTimeWindow(update.Message.Text)
+				}
+				if err != nil {
+					text := tr(lang, "invalid_format")
+					if errors.Is(err, errRandomWindowTooShort) {
+						text = trn(lang, "random_window_too_short", minRandomSendsSpacing)
 					}
-					text, err := getStatsMessageText(startDate, endDate, "month")
-					if err != nil {
-						sendErrorMessage(chatId)
-						return
+					message := SendMessage{
+						ChatId: chatId,
+						Text:   text,
 					}
-					go sendMessage(SendMessage{
-						ChatId:      chatId,
-						Text:        text,
-						ParseMode:   "MarkdownV2",
-						ReplyMarkup: ReplyKeyboardRemove,
-					})
+					sendMessage(message)
 					return
 				}
+				if messageStatus == MessageStatusEditRandomDays {
+					finishEditingRandomTime(chatId, lang, "", weekDays)
+				} else {
+					finishEditingRandomTime(chatId, lang, update.Message.Text, 0)
+				}
+				return
 			}
-			if update.Message.Text == "/start" || update.Message.Text == "/start@"+BotName {
-				dbStatPlusOne(statsDay, "cmd_start")
-				message := getStartMessage(chatId)
-				go sendMessage(message)
-				dbUpdateMessageStatus(chatId, MessageStatusSetTimezone)
+		}
+		if messageStatus == MessageStatusAddSolarLocation {
+			if update.Message.Location == nil {
+				sendMessage(SendMessage{
+					ChatId: chatId,
+					Text:   tr(lang, "prompt_solar_location"),
+				})
 				return
 			}
-			messageStatus, err := dbGetMessageStatus(chatId)
+			err := storage.UpdateChatCoordinates(chatId, update.Message.Location.Latitude, update.Message.Location.Longitude)
 			if err != nil {
-				sendErrorMessage(chatId)
+				sendErrorMessage(chatId, lang)
+				return
 			}
-			if messageStatus >= 1 && messageStatus <= 5 {
-				if update.Message.Text != "" {
-					var crons []string
-					var err error = nil
-					if messageStatus == MessageStatusAddCronCron {
-						for _, cron := range strings.Split(update.Message.Text, ";") {
-							trimmed := strings.Trim(cron, " ")
-							if checkValidCron(trimmed) {
-								crons = append(crons, trimmed)
-							} else {
-								message := SendMessage{
-									ChatId: chatId,
-									Text:   "Некорректный формат. Попробуйте ещё раз",
-								}
-								go sendMessage(message)
-								return
-							}
-						}
-					} else if messageStatus == MessageStatusAddCron1 {
-						var cron string
-						cron, err = parseTimeToCron(update.Message.Text)
-						crons = []string{cron}
-					} else if messageStatus == MessageStatusAddCron2 {
-						crons, err = parseListTimesToCron(update.Message.Text)
-					} else if messageStatus == MessageStatusAddCron3 {
-						var cron string
-						cron, err = parseWeekDayTimeToCron(update.Message.Text)
-						crons = []string{cron}
-					} else if messageStatus == MessageStatusAddCron4 {
-						crons, err = parseListWeekDayTimesToCron(update.Message.Text)
-					}
-					if err != nil {
-						message := SendMessage{
-							ChatId: chatId,
-							Text:   "Некорректный формат. Попробуйте ещё раз",
-						}
-						go sendMessage(message)
-						return
-					}
-					err = addCronsForChat(crons, chatId, false)
-					if err != nil {
-						if errors.Is(err, errExistingCron) {
-							go sendMessage(SendMessage{
-								ChatId:      chatId,
-								Text:        "Такое расписание уже установлено",
-								ReplyMarkup: ReplyKeyboardRemove,
-							})
-							return
-						} else {
-							sendErrorMessage(chatId)
-							return
-						}
-					}
-					dbUpdateMessageStatus(chatId, MessageStatusDefault)
-					message := SendMessage{
-						ChatId:      chatId,
-						Text:        "Расписание успешно добавлено",
-						ReplyMarkup: ReplyKeyboardRemove,
-					}
-					go sendMessage(message)
+			storage.UpdateMessageStatus(chatId, MessageStatusAddSolar)
+			sendMessage(SendMessage{
+				ChatId:      chatId,
+				Text:        tr(lang, "location_saved"),
+				ReplyMarkup: ReplyKeyboardRemove,
+			})
+			sendMessage(SendMessage{
+				ChatId:      chatId,
+				Text:        tr(lang, "prompt_solar"),
+				ReplyMarkup: solarKeyboard(lang),
+			})
+			return
+		}
+		if messageStatus == MessageStatusAddSolar {
+			if update.Message.Text != "" {
+				solar, err := parseSolarSchedule(update.Message.Text)
+				if err != nil {
+					sendMessage(SendMessage{
+						ChatId: chatId,
+						Text:   tr(lang, "invalid_format"),
+					})
 					return
 				}
+				finishAddingSolar(chatId, lang, solar)
+				return
 			}
-			if messageStatus == MessageStatusAddCron5 {
-				if update.Message.Text != "" {
-					times, err := parseListTimes(update.Message.Text)
-					if err != nil || len(times) != 2 {
-						message := SendMessage{
-							ChatId: chatId,
-							Text:   "Некорректный формат. Попробуйте ещё раз",
-						}
-						go sendMessage(message)
-						return
-					}
-					addRandomTimeRegular(chatId, times[0], times[1])
-					dbUpdateMessageStatus(chatId, MessageStatusDefault)
-					message := SendMessage{
-						ChatId: chatId,
-						Text:   "Расписание успешно добавлено",
-					}
-					go sendMessage(message)
+		}
+		if messageStatus == MessageStatusSetDates {
+			if update.Message.Text != "" {
+				dates, err := parseDateBounds(update.Message.Text)
+				if err != nil {
+					sendMessage(SendMessage{
+						ChatId:    chatId,
+						Text:      tr(lang, "prompt_dates"),
+						ParseMode: "MarkdownV2",
+					})
 					return
 				}
+				finishSettingDates(chatId, lang, dates)
+				return
 			}
-			if messageStatus == MessageStatusSetTimezone {
-				var timezone string
-				if update.Message.Location != nil {
-					var err1 error
-					timezone, err1 = getTimezoneByLocation(*update.Message.Location)
-					_, err2 := time.LoadLocation(timezone)
-					if err1 != nil || err2 != nil {
-						message := SendMessage{
-							ChatId: chatId,
-							Text: "Не удалось определить часовой пояс по местоположению\\. Можете попробовать ещё раз, или отправить " +
-								"[название](https://en.wikipedia.org/wiki/List_of_tz_database_time_zones) часового пояса " +
-								"\\(Например: `Europe/Moscow`\\)\\.",
-							ParseMode:          "MarkdownV2",
-							LinkPreviewOptions: LinkPreviewOptions{true},
-						}
-						go sendMessage(message)
-						return
-					}
-				} else {
-					timezone = getTimezoneByDiff(update.Message.Text)
-					_, err := time.LoadLocation(timezone)
-					if err != nil {
-						message := SendMessage{
-							ChatId: chatId,
-							Text: "Не удалось определить часовой пояс\\. Можете попробовать ещё раз\\. Названия часовых поясов можно посмотреть " +
-								"[здесь](https://en.wikipedia.org/wiki/List_of_tz_database_time_zones)\\. " +
-								"Примеры: `Europe/Moscow`, `America/Los_Angeles`\\.",
-							ParseMode: "MarkdownV2",
-						}
-						go sendMessage(message)
-						return
+		}
+		if messageStatus == MessageStatusSetTimezone {
+			var timezone string
+			if update.Message.Location != nil {
+				var err1 error
+				timezone, err1 = getTimezoneByLocation(*update.Message.Location)
+				_, err2 := time.LoadLocation(timezone)
+				if err1 != nil || err2 != nil {
+					message := SendMessage{
+						ChatId: chatId,
+						Text:               tr(lang, "timezone_by_location_failed"),
+						ParseMode:          "MarkdownV2",
+						LinkPreviewOptions: LinkPreviewOptions{true},
 					}
-				}
-				dbUpdateChatData(chatId, MessageStatusDefault, timezone)
-				go recreateJobsForChat(chatId)
-				text := "Часовой пояс `" + displayTimezone(timezone) + "` успешно установлен\\. "
-				crons, err := dbGetAllCrons(chatId)
-				if err != nil {
-					sendErrorMessage(chatId)
+					sendMessage(message)
 					return
 				}
-				if len(crons) == 0 {
+			} else {
+				timezone = getTimezoneByDiff(update.Message.Text)
+				_, err := time.LoadLocation(timezone)
+				if err != nil {
 					message := SendMessage{
-						ChatId:      chatId,
-						Text:        text,
-						ParseMode:   "MarkdownV2",
-						ReplyMarkup: ReplyKeyboardRemove,
+						ChatId: chatId,
+						Text:      tr(lang, "timezone_failed"),
+						ParseMode: "MarkdownV2",
 					}
-					go sendMessage(message)
+					sendMessage(message)
 					return
 				}
-				if len(crons) == 1 {
-					text += "Текущее расписание будет считаться по новому поясу\\."
-				} else {
-					text += "Текущие расписания будут считаться по новому поясу\\."
-				}
-				for _, cron := range crons {
-					text += "\n" + escapingSymbols(cronToString(cron))
-				}
+			}
+			err := changeChatTimezone(chatId, timezone)
+			if err != nil {
+				sendErrorReport(err, "error changing chat timezone")
+				println(err.Error())
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			text := tr(lang, "timezone_set", displayTimezone(timezone))
+			crons, err := storage.GetAllCrons(chatId)
+			if err != nil {
+				sendErrorMessage(chatId, lang)
+				return
+			}
+			if len(crons) == 0 {
 				message := SendMessage{
 					ChatId:      chatId,
 					Text:        text,
 					ParseMode:   "MarkdownV2",
 					ReplyMarkup: ReplyKeyboardRemove,
 				}
-				go sendMessage(message)
-				return
-			} else if messageStatus == MessageStatusBroadcast {
-				if update.Message.From.Id == adminId {
-					if update.Message.Text != "" {
-						dbUpdateMessageStatus(chatId, MessageStatusDefault)
-						broadcastMessageToAll(update.Message.Text, update.Message.Entities)
-						message := SendMessage{
-							ChatId:      adminId,
-							Text:        "Сообщение разослано",
-							ReplyMarkup: ReplyKeyboardRemove,
-						}
-						go sendMessage(message)
-						return
+				sendMessage(message)
+				return
+			}
+			if len(crons) == 1 {
+				text += tr(lang, "timezone_schedule_kept")
+			} else {
+				text += tr(lang, "timezone_schedules_kept")
+			}
+			for _, cron := range crons {
+				text += "\n" + escapingSymbols(cronToString(cron, lang))
+			}
+			message := SendMessage{
+				ChatId:      chatId,
+				Text:        text,
+				ParseMode:   "MarkdownV2",
+				ReplyMarkup: ReplyKeyboardRemove,
+			}
+			sendMessage(message)
+			return
+		} else if messageStatus == MessageStatusBroadcast {
+			if update.Message.From.Id == adminId {
+				if update.Message.Text != "" {
+					storage.UpdateMessageStatus(chatId, MessageStatusDefault)
+					broadcastMessageToAll(update.Message.Text, update.Message.Entities)
+					message := SendMessage{
+						ChatId:      adminId,
+						Text:        "Сообщение разослано",
+						ReplyMarkup: ReplyKeyboardRemove,
 					}
+					sendMessage(message)
+					return
 				}
 			}
+		}
+		return
+	}
+}
+
+func finishAddingRandomTime(chatId int64, lang Language, weekDays int) {
+	pendingInput, err := storage.GetPendingInput(chatId)
+	if err != nil {
+		sendErrorMessage(chatId, lang)
+		return
+	}
+	startTime, endTime, count, err := parseRandomTimeWindow(pendingInput)
+	if err != nil {
+		sendErrorMessage(chatId, lang)
+		return
+	}
+	err = addRandomTimeRegular(chatId, startTime, endTime, weekDays, count)
+	if err != nil {
+		if errors.Is(err, errExistingRandomTime) {
+			sendMessage(SendMessage{
+				ChatId:      chatId,
+				Text:        tr(lang, "schedule_exists"),
+				ReplyMarkup: ReplyKeyboardRemove,
+			})
+		} else {
+			sendErrorMessage(chatId, lang)
+		}
+		return
+	}
+	storage.UpdateMessageStatusAndPendingInput(chatId, MessageStatusDefault, "")
+	message := SendMessage{
+		ChatId: chatId,
+		Text:   tr(lang, "schedule_added"),
+	}
+	sendMessage(message)
+}
+
+func randomDaysKeyboard(lang Language) InlineKeyboardMarkup {
+	return InlineKeyboardMarkup{[][]InlineKeyboardButton{
+		{{tr(lang, "days_every_day"), "randomdays:" + strconv.Itoa(everyDayMask)}},
+		{{tr(lang, "days_work_days"), "randomdays:" + strconv.Itoa(workDaysMask)},
+			{tr(lang, "days_weekends"), "randomdays:" + strconv.Itoa(weekendsMask)}},
+	}}
+}
+
+func finishEditingRandomTime(chatId int64, lang Language, window string, weekDays int) {
+	pendingInput, err := storage.GetPendingInput(chatId)
+	if err != nil {
+		sendErrorMessage(chatId, lang)
+		return
+	}
+	id, err := strconv.Atoi(pendingInput)
+	if err != nil {
+		sendErrorMessage(chatId, lang)
+		return
+	}
+	randomTime, err := getRandomTimeForChat(chatId, id)
+	if err != nil {
+		sendErrorMessage(chatId, lang)
+		return
+	}
+	if window != "" {
+		startTime, endTime, count, err := parseRandomTimeWindow(window)
+		if err != nil {
+			sendErrorMessage(chatId, lang)
 			return
 		}
-		writer.WriteHeader(200)
+		randomTime.StartTime = startTime
+		randomTime.Duration = (endTime - startTime + 24*60) % (24 * 60)
+		randomTime.Count = count
+	} else {
+		randomTime.WeekDays = weekDays
+	}
+	err = updateRandomTimeRegular(chatId, randomTime)
+	if err != nil {
+		if errors.Is(err, errExistingRandomTime) {
+			sendMessage(SendMessage{
+				ChatId: chatId,
+				Text:   tr(lang, "schedule_exists"),
+			})
+		} else {
+			sendErrorMessage(chatId, lang)
+		}
+		return
+	}
+	storage.UpdateMessageStatusAndPendingInput(chatId, MessageStatusDefault, "")
+	message := SendMessage{
+		ChatId: chatId,
+		Text:   tr(lang, "schedule_changed", randomTimeToString(randomTime, lang)),
+	}
+	sendMessage(message)
+}
+
+func finishAddingCrons(chatId int64, lang Language, crons []string) {
+	err := addCronsForChat(crons, chatId, false)
+	if err != nil {
+		if errors.Is(err, errExistingCron) {
+			sendMessage(SendMessage{
+				ChatId:      chatId,
+				Text:        tr(lang, "schedule_exists"),
+				ReplyMarkup: ReplyKeyboardRemove,
+			})
+		} else {
+			sendErrorMessage(chatId, lang)
+		}
+		return
+	}
+	storage.UpdateMessageStatusAndPendingInput(chatId, MessageStatusDefault, "")
+	message := SendMessage{
+		ChatId:      chatId,
+		Text:        tr(lang, "schedule_added"),
+		ReplyMarkup: ReplyKeyboardRemove,
+	}
+	sendMessage(message)
+}
+
+func finishSettingDates(chatId int64, lang Language, dates DateBounds) {
+	pendingInput, err := storage.GetPendingInput(chatId)
+	if err != nil {
+		sendErrorMessage(chatId, lang)
+		return
+	}
+	var description string
+	if strings.HasPrefix(pendingInput, "cron:") {
+		cron := pendingInput[5:]
+		err = storage.UpdateCronDates(chatId, cron, dates)
+		description = cronToString(cron, lang)
+	} else if strings.HasPrefix(pendingInput, "random:") {
+		var id int
+		id, err = strconv.Atoi(pendingInput[7:])
+		if err != nil {
+			sendErrorMessage(chatId, lang)
+			return
+		}
+		var randomTime RandomTimeVerse
+		randomTime, err = getRandomTimeForChat(chatId, id)
+		if err != nil {
+			sendErrorMessage(chatId, lang)
+			return
+		}
+		randomTime.Dates = dates
+		err = updateRandomTimeRegular(chatId, randomTime)
+		description = randomTimeToString(randomTime, lang)
+	} else {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		sendErrorMessage(chatId, lang)
+		return
+	}
+	storage.UpdateMessageStatusAndPendingInput(chatId, MessageStatusDefault, "")
+	sendMessage(SendMessage{
+		ChatId: chatId,
+		Text:   tr(lang, "dates_set", withDates(description, dates, lang)),
+	})
+}
+
+func finishAddingSolar(chatId int64, lang Language, solar SolarVerse) {
+	err := addSolarSchedule(chatId, solar)
+	if err != nil {
+		if errors.Is(err, errExistingSolar) {
+			sendMessage(SendMessage{
+				ChatId: chatId,
+				Text:   tr(lang, "schedule_exists"),
+			})
+		} else {
+			sendErrorMessage(chatId, lang)
+		}
+		return
+	}
+	storage.UpdateMessageStatus(chatId, MessageStatusDefault)
+	sendMessage(SendMessage{
+		ChatId: chatId,
+		Text:   tr(lang, "schedule_added") + ": " + solarToString(solar, lang),
 	})
-	port := os.Getenv("LOCAL_PORT")
-	log.Fatal(http.ListenAndServe(":"+port, nil))
 }
@@ -0,0 +1,935 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+var errAlreadyExists = errors.New("already exists")
+
+type memoryChat struct {
+	chatType      int
+	messageStatus MessageStatus
+	timezone      string
+	language      string
+	pendingInput  string
+	paused        bool
+	pausedUntil   sql.NullTime
+	latitude      sql.NullFloat64
+	longitude     sql.NullFloat64
+	calendar      string
+	verseSource   string
+}
+
+type memoryCron struct {
+	chatId     int64
+	cron       string
+	lastSent   time.Time
+	dates      DateBounds
+	nextFireAt sql.NullTime
+	claimed    bool
+}
+
+type memoryRandomTime struct {
+	chatId     int64
+	randomTime RandomTimeVerse
+}
+
+type memorySolar struct {
+	chatId     int64
+	solar      SolarVerse
+	nextFireAt sql.NullTime
+	claimed    bool
+}
+
+type memoryReminder struct {
+	chatId   int64
+	reminder Reminder
+}
+
+type memoryStat struct {
+	date  string
+	name  string
+	count int
+}
+
+type memoryUpdate struct {
+	receivedAt time.Time
+	handled    bool
+}
+
+type memoryStatChat struct {
+	date   string
+	name   string
+	chatId int64
+}
+
+// memoryStorage is the Storage of a single instance without a database. It behaves like
+// the Postgres storage, including the unique constraints, but loses everything on restart.
+type memoryStorage struct {
+	mutex            sync.Mutex
+	chats            map[int64]*memoryChat
+	crons            []*memoryCron
+	randomTimes      map[int]*memoryRandomTime
+	solars           map[int]*memorySolar
+	reminders        map[int]*memoryReminder
+	lastId           int
+	stats            []*memoryStat
+	statsListChats   []memoryStatChat
+	processedUpdates map[int64]*memoryUpdate
+	versesLists      map[int]*VersesList
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{
+		chats:            make(map[int64]*memoryChat),
+		randomTimes:      make(map[int]*memoryRandomTime),
+		solars:           make(map[int]*memorySolar),
+		reminders:        make(map[int]*memoryReminder),
+		processedUpdates: make(map[int64]*memoryUpdate),
+		versesLists:      make(map[int]*VersesList),
+	}
+}
+
+func (s *memoryStorage) nextId() int {
+	s.lastId++
+	return s.lastId
+}
+
+// chat returns the chat, or a throwaway one for updates of chats that do not exist.
+func (s *memoryStorage) chat(chatId int64) *memoryChat {
+	if chat, ok := s.chats[chatId]; ok {
+		return chat
+	}
+	return &memoryChat{}
+}
+
+func (s *memoryStorage) getChat(chatId int64) (*memoryChat, error) {
+	chat, ok := s.chats[chatId]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return chat, nil
+}
+
+func (s *memoryStorage) AddChat(chatId int64, chatType TelegramChatType, language Language) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	chat, ok := s.chats[chatId]
+	if !ok {
+		chat = &memoryChat{}
+		s.chats[chatId] = chat
+	}
+	chat.chatType = chatTypeToInt(chatType)
+	if chat.language == "" {
+		chat.language = string(language)
+	}
+	return nil
+}
+
+func (s *memoryStorage) GetAllChats() ([]int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	result := []int64{}
+	for chatId := range s.chats {
+		result = append(result, chatId)
+	}
+	return result, nil
+}
+
+func (s *memoryStorage) GetMessageStatus(chatId int64) (MessageStatus, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	chat, err := s.getChat(chatId)
+	if err != nil {
+		return MessageStatusDefault, err
+	}
+	return chat.messageStatus, nil
+}
+
+func (s *memoryStorage) UpdateMessageStatus(chatId int64, messageStatus MessageStatus) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.chat(chatId).messageStatus = messageStatus
+	return nil
+}
+
+func (s *memoryStorage) UpdateChatData(chatId int64, messageStatus MessageStatus, timezone string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	chat := s.chat(chatId)
+	chat.messageStatus = messageStatus
+	chat.timezone = timezone
+	for _, randomTime := range s.randomTimes {
+		if randomTime.chatId == chatId {
+			randomTime.randomTime.NextSends = []time.Time{}
+		}
+	}
+	return nil
+}
+
+func (s *memoryStorage) GetPendingInput(chatId int64) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	chat, err := s.getChat(chatId)
+	if err != nil {
+		return "", err
+	}
+	return chat.pendingInput, nil
+}
+
+func (s *memoryStorage) UpdateMessageStatusAndPendingInput(chatId int64, messageStatus MessageStatus, pendingInput string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	chat := s.chat(chatId)
+	chat.messageStatus = messageStatus
+	chat.pendingInput = pendingInput
+	return nil
+}
+
+func (s *memoryStorage) GetPause(chatId int64) (bool, sql.NullTime, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	chat, err := s.getChat(chatId)
+	if err != nil {
+		return false, sql.NullTime{}, err
+	}
+	return chat.paused, chat.pausedUntil, nil
+}
+
+func (s *memoryStorage) UpdatePause(chatId int64, paused bool, pausedUntil sql.NullTime) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	chat := s.chat(chatId)
+	chat.paused = paused
+	chat.pausedUntil = pausedUntil
+	return nil
+}
+
+func (s *memoryStorage) ClearExpiredPauses() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	for _, chat := range s.chats {
+		if chat.paused && chat.pausedUntil.Valid && chat.pausedUntil.Time.Before(now) {
+			chat.paused = false
+			chat.pausedUntil = sql.NullTime{}
+		}
+	}
+	return nil
+}
+
+func (s *memoryStorage) GetTimezone(chatId int64) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	chat, err := s.getChat(chatId)
+	if err != nil {
+		return "", err
+	}
+	return chat.timezone, nil
+}
+
+func (s *memoryStorage) GetLanguage(chatId int64) (Language, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	chat, err := s.getChat(chatId)
+	if err != nil {
+		return defaultLanguage, err
+	}
+	lang, ok := parseLanguage(chat.language)
+	if !ok {
+		return defaultLanguage, nil
+	}
+	return lang, nil
+}
+
+func (s *memoryStorage) UpdateLanguage(chatId int64, language Language) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.chat(chatId).language = string(language)
+	return nil
+}
+
+func (s *memoryStorage) GetCalendarTradition(chatId int64) (CalendarTradition, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	chat, err := s.getChat(chatId)
+	if err != nil {
+		return "", err
+	}
+	tradition, _ := parseCalendarTradition(chat.calendar)
+	return tradition, nil
+}
+
+func (s *memoryStorage) UpdateCalendarTradition(chatId int64, tradition CalendarTradition) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.chat(chatId).calendar = string(tradition)
+	return nil
+}
+
+func (s *memoryStorage) GetVerseSource(chatId int64) (VerseSource, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	chat, err := s.getChat(chatId)
+	if err != nil {
+		return VerseSourceRandom, err
+	}
+	if VerseSource(chat.verseSource) == VerseSourceCalendar {
+		return VerseSourceCalendar, nil
+	}
+	return VerseSourceRandom, nil
+}
+
+func (s *memoryStorage) UpdateVerseSource(chatId int64, source VerseSource) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.chat(chatId).verseSource = string(source)
+	return nil
+}
+
+func (s *memoryStorage) GetChatCoordinates(chatId int64) (float64, float64, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	chat, err := s.getChat(chatId)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	return chat.latitude.Float64, chat.longitude.Float64, chat.latitude.Valid && chat.longitude.Valid, nil
+}
+
+func (s *memoryStorage) UpdateChatCoordinates(chatId int64, latitude float64, longitude float64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	chat := s.chat(chatId)
+	chat.latitude = sql.NullFloat64{Float64: latitude, Valid: true}
+	chat.longitude = sql.NullFloat64{Float64: longitude, Valid: true}
+	return nil
+}
+
+func (s *memoryStorage) ForgetChatCoordinates(chatId int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for id, solar := range s.solars {
+		if solar.chatId == chatId {
+			delete(s.solars, id)
+		}
+	}
+	chat := s.chat(chatId)
+	chat.latitude = sql.NullFloat64{}
+	chat.longitude = sql.NullFloat64{}
+	return nil
+}
+
+func (s *memoryStorage) findCron(chatId int64, cron string) *memoryCron {
+	for _, c := range s.crons {
+		if c.chatId == chatId && c.cron == cron {
+			return c
+		}
+	}
+	return nil
+}
+
+func (s *memoryStorage) AddCron(chatId int64, cron string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.findCron(chatId, cron) != nil {
+		return errAlreadyExists
+	}
+	s.crons = append(s.crons, &memoryCron{chatId: chatId, cron: cron, lastSent: time.Now()})
+	return nil
+}
+
+func (s *memoryStorage) GetAllCrons(chatId int64) ([]string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	result := []string{}
+	for _, c := range s.crons {
+		if c.chatId == chatId {
+			result = append(result, c.cron)
+		}
+	}
+	return result, nil
+}
+
+func (s *memoryStorage) GetCronsLastSent(chatId int64) (map[string]time.Time, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	result := make(map[string]time.Time)
+	for _, c := range s.crons {
+		if c.chatId == chatId {
+			result[c.cron] = c.lastSent
+		}
+	}
+	return result, nil
+}
+
+func (s *memoryStorage) UpdateCronLastSent(chatId int64, cron string, lastSent time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if c := s.findCron(chatId, cron); c != nil {
+		c.lastSent = lastSent
+	}
+	return nil
+}
+
+func (s *memoryStorage) GetCronsDates(chatId int64) (map[string]DateBounds, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	result := make(map[string]DateBounds)
+	for _, c := range s.crons {
+		if c.chatId == chatId {
+			result[c.cron] = c.dates
+		}
+	}
+	return result, nil
+}
+
+func (s *memoryStorage) UpdateCronDates(chatId int64, cron string, dates DateBounds) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if c := s.findCron(chatId, cron); c != nil {
+		c.dates = dates
+	}
+	return nil
+}
+
+func (s *memoryStorage) ReplaceCron(chatId int64, oldCron string, newCron string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	c := s.findCron(chatId, oldCron)
+	if c == nil {
+		return sql.ErrNoRows
+	}
+	if oldCron != newCron && s.findCron(chatId, newCron) != nil {
+		return errAlreadyExists
+	}
+	c.cron = newCron
+	return nil
+}
+
+func (s *memoryStorage) RemoveCron(chatId int64, cron string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.crons = slices.DeleteFunc(s.crons, func(c *memoryCron) bool {
+		return c.chatId == chatId && c.cron == cron
+	})
+	return nil
+}
+
+// copyRandomTime returns the random time with its own sorted copy of the next sends.
+func copyRandomTime(randomTime RandomTimeVerse) RandomTimeVerse {
+	randomTime.NextSends = slices.Clone(randomTime.NextSends)
+	if randomTime.NextSends == nil {
+		randomTime.NextSends = []time.Time{}
+	}
+	slices.SortFunc(randomTime.NextSends, func(a, b time.Time) int { return a.Compare(b) })
+	return randomTime
+}
+
+func (s *memoryStorage) AddRandomTime(chatId int64, randomTime RandomTimeVerse) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, rt := range s.randomTimes {
+		if rt.chatId == chatId && rt.randomTime.WeekDays == randomTime.WeekDays &&
+			rt.randomTime.StartTime == randomTime.StartTime && rt.randomTime.Duration == randomTime.Duration {
+			return 0, errAlreadyExists
+		}
+	}
+	id := s.nextId()
+	s.randomTimes[id] = &memoryRandomTime{chatId, RandomTimeVerse{
+		Id: id, WeekDays: randomTime.WeekDays, StartTime: randomTime.StartTime,
+		Duration: randomTime.Duration, Count: randomTime.Count, NextSends: []time.Time{}}}
+	return id, nil
+}
+
+func (s *memoryStorage) GetAllRandomTimes(chatId int64) ([]RandomTimeVerse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	result := []RandomTimeVerse{}
+	for _, rt := range s.randomTimes {
+		if rt.chatId == chatId {
+			result = append(result, copyRandomTime(rt.randomTime))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Id < result[j].Id })
+	return result, nil
+}
+
+func (s *memoryStorage) GetRandomTimeById(randomTimeId int) (RandomTimeVerse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	rt, ok := s.randomTimes[randomTimeId]
+	if !ok {
+		return RandomTimeVerse{}, sql.ErrNoRows
+	}
+	return copyRandomTime(rt.randomTime), nil
+}
+
+func (s *memoryStorage) UpdateRandomTime(chatId int64, randomTime RandomTimeVerse) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	rt, ok := s.randomTimes[randomTime.Id]
+	if !ok || rt.chatId != chatId {
+		return nil
+	}
+	rt.randomTime.WeekDays = randomTime.WeekDays
+	rt.randomTime.StartTime = randomTime.StartTime
+	rt.randomTime.Duration = randomTime.Duration
+	rt.randomTime.Count = randomTime.Count
+	rt.randomTime.Dates = randomTime.Dates
+	rt.randomTime.NextSends = []time.Time{}
+	return nil
+}
+
+func (s *memoryStorage) RemoveRandomTime(chatId int64, randomTimeId int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if rt, ok := s.randomTimes[randomTimeId]; ok && rt.chatId == chatId {
+		delete(s.randomTimes, randomTimeId)
+	}
+	return nil
+}
+
+func (s *memoryStorage) AddNextSend(randomTimeId int, send time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	rt, ok := s.randomTimes[randomTimeId]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if !slices.ContainsFunc(rt.randomTime.NextSends, send.Equal) {
+		rt.randomTime.NextSends = append(rt.randomTime.NextSends, send)
+	}
+	return nil
+}
+
+func (s *memoryStorage) RemoveNextSend(randomTimeId int, send time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if rt, ok := s.randomTimes[randomTimeId]; ok {
+		rt.randomTime.NextSends = slices.DeleteFunc(rt.randomTime.NextSends, send.Equal)
+	}
+	return nil
+}
+
+func (s *memoryStorage) ClearOldSends() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	for _, rt := range s.randomTimes {
+		rt.randomTime.NextSends = slices.DeleteFunc(rt.randomTime.NextSends, func(send time.Time) bool {
+			return send.Before(now)
+		})
+	}
+	return nil
+}
+
+func (s *memoryStorage) AddSolarSchedule(chatId int64, solar SolarVerse) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, existing := range s.solars {
+		if existing.chatId == chatId && existing.solar.Event == solar.Event && existing.solar.Offset == solar.Offset {
+			return 0, errAlreadyExists
+		}
+	}
+	solar.Id = s.nextId()
+	s.solars[solar.Id] = &memorySolar{chatId: chatId, solar: solar}
+	return solar.Id, nil
+}
+
+func (s *memoryStorage) GetAllSolarSchedules(chatId int64) ([]SolarVerse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	result := []SolarVerse{}
+	for _, solar := range s.solars {
+		if solar.chatId == chatId {
+			result = append(result, solar.solar)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Event != result[j].Event {
+			return result[i].Event < result[j].Event
+		}
+		return result[i].Offset < result[j].Offset
+	})
+	return result, nil
+}
+
+func (s *memoryStorage) RemoveSolarSchedule(chatId int64, solarId int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if solar, ok := s.solars[solarId]; ok && solar.chatId == chatId {
+		delete(s.solars, solarId)
+	}
+	return nil
+}
+
+func (s *memoryStorage) AddReminder(chatId int64, sendAt time.Time) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	id := s.nextId()
+	s.reminders[id] = &memoryReminder{chatId, Reminder{id, sendAt}}
+	return id, nil
+}
+
+func (s *memoryStorage) GetAllReminders(chatId int64) ([]Reminder, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	result := []Reminder{}
+	for _, reminder := range s.reminders {
+		if reminder.chatId == chatId {
+			result = append(result, reminder.reminder)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].SendAt.Before(result[j].SendAt) })
+	return result, nil
+}
+
+func (s *memoryStorage) RemoveReminder(chatId int64, reminderId int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if reminder, ok := s.reminders[reminderId]; ok && reminder.chatId == chatId {
+		delete(s.reminders, reminderId)
+	}
+	return nil
+}
+
+func (s *memoryStorage) ClearOldReminders() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	dayAgo := time.Now().AddDate(0, 0, -1)
+	for id, reminder := range s.reminders {
+		if reminder.reminder.SendAt.Before(dayAgo) {
+			delete(s.reminders, id)
+		}
+	}
+	return nil
+}
+
+func (s *memoryStorage) RemoveAllSchedulesForChat(chatId int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.crons = slices.DeleteFunc(s.crons, func(c *memoryCron) bool { return c.chatId == chatId })
+	for id, rt := range s.randomTimes {
+		if rt.chatId == chatId {
+			delete(s.randomTimes, id)
+		}
+	}
+	for id, reminder := range s.reminders {
+		if reminder.chatId == chatId {
+			delete(s.reminders, id)
+		}
+	}
+	for id, solar := range s.solars {
+		if solar.chatId == chatId {
+			delete(s.solars, id)
+		}
+	}
+	return nil
+}
+
+// isDue orders schedules without a next fire time first, like "nulls first" in Postgres.
+func isDue(nextFireAt sql.NullTime, now time.Time) bool {
+	return !nextFireAt.Valid || !nextFireAt.Time.After(now)
+}
+
+func compareNextFires(a sql.NullTime, b sql.NullTime) int {
+	if !a.Valid || !b.Valid {
+		if a.Valid == b.Valid {
+			return 0
+		}
+		if !a.Valid {
+			return -1
+		}
+		return 1
+	}
+	return a.Time.Compare(b.Time)
+}
+
+// AdvanceDueCrons marks the due crons as claimed and calls advance without holding
+// the mutex, as advance reads the storage itself.
+func (s *memoryStorage) AdvanceDueCrons(now time.Time, limit int, advance func(cron DueCron) time.Time) (int, error) {
+	s.mutex.Lock()
+	due := []*memoryCron{}
+	for _, c := range s.crons {
+		if !c.claimed && isDue(c.nextFireAt, now) {
+			due = append(due, c)
+		}
+	}
+	slices.SortStableFunc(due, func(a, b *memoryCron) int { return compareNextFires(a.nextFireAt, b.nextFireAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	crons := []DueCron{}
+	for _, c := range due {
+		c.claimed = true
+		crons = append(crons, DueCron{c.chatId, c.cron, c.lastSent, c.nextFireAt})
+	}
+	s.mutex.Unlock()
+	for i, cron := range crons {
+		nextFireAt := sql.NullTime{Time: advance(cron), Valid: true}
+		s.mutex.Lock()
+		due[i].nextFireAt = nextFireAt
+		due[i].claimed = false
+		s.mutex.Unlock()
+	}
+	return len(crons), nil
+}
+
+func (s *memoryStorage) AdvanceDueSolars(now time.Time, limit int, advance func(solar DueSolar) time.Time) (int, error) {
+	s.mutex.Lock()
+	due := []*memorySolar{}
+	for _, solar := range s.solars {
+		if !solar.claimed && isDue(solar.nextFireAt, now) {
+			due = append(due, solar)
+		}
+	}
+	slices.SortStableFunc(due, func(a, b *memorySolar) int { return compareNextFires(a.nextFireAt, b.nextFireAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	solars := []DueSolar{}
+	for _, solar := range due {
+		solar.claimed = true
+		solars = append(solars, DueSolar{solar.chatId, solar.solar, solar.nextFireAt})
+	}
+	s.mutex.Unlock()
+	for i, solar := range solars {
+		nextFireAt := sql.NullTime{Time: advance(solar), Valid: true}
+		s.mutex.Lock()
+		due[i].nextFireAt = nextFireAt
+		due[i].claimed = false
+		s.mutex.Unlock()
+	}
+	return len(solars), nil
+}
+
+func (s *memoryStorage) ResetCronsNextFire(chatId int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, c := range s.crons {
+		if c.chatId == chatId {
+			c.nextFireAt = sql.NullTime{}
+		}
+	}
+	return nil
+}
+
+func (s *memoryStorage) ResetSolarsNextFire(chatId int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, solar := range s.solars {
+		if solar.chatId == chatId {
+			solar.nextFireAt = sql.NullTime{}
+		}
+	}
+	return nil
+}
+
+func (s *memoryStorage) TakeDueNextSends(now time.Time, limit int) ([]DueSend, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	type nextSend struct {
+		randomTime *memoryRandomTime
+		at         time.Time
+	}
+	due := []nextSend{}
+	for _, rt := range s.randomTimes {
+		for _, send := range rt.randomTime.NextSends {
+			if !send.After(now) {
+				due = append(due, nextSend{rt, send})
+			}
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].at.Before(due[j].at) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	sends := []DueSend{}
+	for _, send := range due {
+		send.randomTime.randomTime.NextSends = slices.DeleteFunc(send.randomTime.randomTime.NextSends, send.at.Equal)
+		sends = append(sends, DueSend{send.randomTime.chatId, send.at})
+	}
+	return sends, nil
+}
+
+func (s *memoryStorage) TakeDueReminders(now time.Time, limit int) ([]DueSend, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	due := []*memoryReminder{}
+	for _, reminder := range s.reminders {
+		if !reminder.reminder.SendAt.After(now) {
+			due = append(due, reminder)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].reminder.SendAt.Before(due[j].reminder.SendAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	sends := []DueSend{}
+	for _, reminder := range due {
+		delete(s.reminders, reminder.reminder.Id)
+		sends = append(sends, DueSend{reminder.chatId, reminder.reminder.SendAt})
+	}
+	return sends, nil
+}
+
+func (s *memoryStorage) ClaimUpdate(updateId int64) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	if update, ok := s.processedUpdates[updateId]; ok {
+		if update.handled || now.Sub(update.receivedAt) < updateClaimTimeout {
+			return false, nil
+		}
+	}
+	s.processedUpdates[updateId] = &memoryUpdate{receivedAt: now}
+	return true, nil
+}
+
+func (s *memoryStorage) MarkUpdateHandled(updateId int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if update, ok := s.processedUpdates[updateId]; ok {
+		update.handled = true
+	}
+	return nil
+}
+
+func (s *memoryStorage) ReleaseUpdate(updateId int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if update, ok := s.processedUpdates[updateId]; ok && !update.handled {
+		delete(s.processedUpdates, updateId)
+	}
+	return nil
+}
+
+func (s *memoryStorage) ClearOldProcessedUpdates() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	twoDaysAgo := time.Now().AddDate(0, 0, -2)
+	for updateId, update := range s.processedUpdates {
+		if update.receivedAt.Before(twoDaysAgo) {
+			delete(s.processedUpdates, updateId)
+		}
+	}
+	return nil
+}
+
+func (s *memoryStorage) StatPlusOne(date string, stat string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, existing := range s.stats {
+		if existing.date == date && existing.name == stat {
+			existing.count++
+			return nil
+		}
+	}
+	s.stats = append(s.stats, &memoryStat{date, stat, 1})
+	return nil
+}
+
+func (s *memoryStorage) StatUpdateChatsList(date string, stat string, chatId int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	entry := memoryStatChat{date, stat, chatId}
+	if !slices.Contains(s.statsListChats, entry) {
+		s.statsListChats = append(s.statsListChats, entry)
+	}
+	return nil
+}
+
+func (s *memoryStorage) GetStatsInRange(startDate string, endDate string) ([]Stats, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	byDate := make(map[string]map[string]int)
+	for _, stat := range s.stats {
+		if stat.date < startDate || stat.date > endDate {
+			continue
+		}
+		if byDate[stat.date] == nil {
+			byDate[stat.date] = make(map[string]int)
+		}
+		byDate[stat.date][stat.name] = stat.count
+	}
+	result := []Stats{}
+	for date, count := range byDate {
+		result = append(result, Stats{date, count})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Date < result[j].Date })
+	return result, nil
+}
+
+func (s *memoryStorage) GetStatsListChatsInRange(startDate string, endDate string) ([]StatsListChats, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	byDate := make(map[string]map[string][]int64)
+	for _, entry := range s.statsListChats {
+		if entry.date < startDate || entry.date > endDate {
+			continue
+		}
+		if byDate[entry.date] == nil {
+			byDate[entry.date] = make(map[string][]int64)
+		}
+		byDate[entry.date][entry.name] = append(byDate[entry.date][entry.name], entry.chatId)
+	}
+	result := []StatsListChats{}
+	for date, chats := range byDate {
+		result = append(result, StatsListChats{date, chats})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Date < result[j].Date })
+	return result, nil
+}
+
+// copyVersesList returns the list with its own copies of the entries and the access.
+func copyVersesList(list *VersesList) VersesList {
+	result := *list
+	result.List = []LongVerse{}
+	for _, verse := range list.List {
+		verse.Verses = slices.Clone(verse.Verses)
+		result.List = append(result.List, verse)
+	}
+	result.Writers = append([]int64{}, list.Writers...)
+	result.Readers = append([]int64{}, list.Readers...)
+	return result
+}
+
+func (s *memoryStorage) GetVersesList(listId int) (VersesList, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	list, ok := s.versesLists[listId]
+	if !ok {
+		return VersesList{}, sql.ErrNoRows
+	}
+	return copyVersesList(list), nil
+}
+
+// importVersesListsFile loads the lists from versesLists.json, keeping their ids.
+func (s *memoryStorage) importVersesListsFile() error {
+	if _, err := os.Stat(versesListsFileName); err != nil {
+		return nil
+	}
+	lists, err := readVersesListsFile()
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i := range lists {
+		list := copyVersesList(&lists[i])
+		s.versesLists[list.Id] = &list
+		s.lastId = max(s.lastId, list.Id)
+	}
+	return nil
+}
+
+func (s *memoryStorage) Close() error {
+	return nil
+}
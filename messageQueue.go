@@ -0,0 +1,45 @@
+package main
+
+import (
+	"sync"
+)
+
+const messageSendersCount = 10
+const messageQueueSize = 1000
+
+var messageQueue = make(chan SendMessage, messageQueueSize)
+var messageQueueClosed = false
+var messageQueueMutex sync.RWMutex
+var messageSendersWaitGroup sync.WaitGroup
+
+func startMessageSenders() {
+	for i := 0; i < messageSendersCount; i++ {
+		messageSendersWaitGroup.Add(1)
+		go func() {
+			defer messageSendersWaitGroup.Done()
+			for m := range messageQueue {
+				sendMessage(m)
+			}
+		}()
+	}
+}
+
+func queueMessage(m SendMessage) {
+	messageQueueMutex.RLock()
+	defer messageQueueMutex.RUnlock()
+	if messageQueueClosed {
+		sendMessage(m)
+		return
+	}
+	messageQueue <- m
+}
+
+func stopMessageSenders() *sync.WaitGroup {
+	messageQueueMutex.Lock()
+	if !messageQueueClosed {
+		messageQueueClosed = true
+		close(messageQueue)
+	}
+	messageQueueMutex.Unlock()
+	return &messageSendersWaitGroup
+}
@@ -0,0 +1,262 @@
+package main
+
+var messagesEn = map[string]string{
+	"internal_error":      "Internal bot error. We are already working on a fix.",
+	"invalid_format":      "Invalid format. Please try again",
+	"operation_cancelled": "Operation cancelled",
+	"random_verse_button": "Next random verse",
+
+	"prompt_natural": "Describe the schedule in your own words, for example: `every day at 8 am`, `on weekdays at 7:30 and 21:00`, " +
+		"`on Sundays at noon`, `every 3 hours in the daytime` or `3 times at random from 9 to 21`\\.\n\nOr choose how often:",
+	"natural_understood":     "Schedule:",
+	"natural_not_understood": "Could not understand the schedule. Try to put it differently, for example: \"on weekdays at 7:30\", or pick an option with the buttons above",
+	"button_once_a_day":      "Once a day",
+	"button_several_a_day":   "Several times a day",
+	"button_once_a_week":     "Once a week",
+	"button_several_a_week":  "Several times a week",
+	"button_random_daily":    "Random time in a window",
+	"button_cron":            "Enter a cron string",
+
+	"prompt_cron": "Enter a string in [cron](https://en.wikipedia.org/wiki/Cron) format \\(Sunday \\- 0\\)\\. " +
+		"Several schedules can be separated with a semicolon\\. " +
+		"For example: `0 9 * * 6,0; 0 6-22/2 * * 1-5`\n",
+	"prompt_time": "Enter the time as `hh:mm`\\. For example: `18:03`, or `07:40`",
+	"prompt_times": "Enter the time as `hh:mm`\\. Several schedules can be separated with a comma\\. " +
+		"For example: `18:03, 07:40`, or `01:00, 10:20, 23:59`",
+	"prompt_weekday_time": "Enter the day of the week number and the time as `d hh:mm`\\. For example: `1 18:03`, or `7 07:40`\\. " +
+		"\\(1 \\- Monday, 7 \\- Sunday\\)",
+	"prompt_weekday_times": "Enter the day of the week number and the time as `d hh:mm`\\. Several schedules can be separated with a comma\\. " +
+		"For example: `1 18:03, 7 07:40`\\. \\(1 \\- Monday, 7 \\- Sunday\\)",
+	"prompt_random_window_days": "Choose the days of the week, or enter their numbers separated with commas\\. " +
+		"For example: `1, 3, 5`\\. \\(1 \\- Monday, 7 \\- Sunday\\)",
+	"days_every_day":  "Every day",
+	"days_work_days":  "On weekdays",
+	"days_weekends":   "On weekends",
+	"weekday_short_0": "Sun",
+	"weekday_short_1": "Mon",
+	"weekday_short_2": "Tue",
+	"weekday_short_3": "Wed",
+	"weekday_short_4": "Thu",
+	"weekday_short_5": "Fri",
+	"weekday_short_6": "Sat",
+	"prompt_random_window": "Enter the start and end of the window for sending at a random time " +
+		"as `hh:mm, hh:mm`\\. For example: `07:40, 18:03`, or `22:00, 02:00`\\. " +
+		"A third number sets how many verses to send in the window: `08:00, 22:00, 3`\\.",
+
+	"current_schedules":         "Current schedules:",
+	"no_schedules":              "No regular schedules",
+	"choose_schedule_to_remove": "Choose a schedule to remove",
+	"schedule_removed":          "Schedule `%s` removed",
+	"random_time_removed":       "Random time schedule removed",
+	"choose_schedule_to_edit":   "Choose a schedule to change",
+	"prompt_edit_cron": "Current schedule: %s\\. Enter the new time as `hh:mm`, a day of the week and time as `d hh:mm`, " +
+		"or a cron string\\. For example: `07:40`, `7 07:40` or `0 9 * * 1-5`",
+	"button_edit_window": "Change the window",
+	"button_edit_days":   "Change the days of the week",
+	"schedule_changed":   "Schedule changed: %s",
+	"paused":             "Schedules are paused",
+	"paused_until":       "Schedules are paused until %s",
+	"resume_hint":        "To resume them earlier, use /resume",
+	"resumed":            "Schedules resumed",
+	"prompt_pause": "Use `/pause` to pause the schedules until you send /resume, " +
+		"or set a period: `/pause 14d`, `/pause 2w`, `/pause 12h` or `/pause 2026\\-12\\-25`",
+	"cron_preview":   "Next sends for this schedule:",
+	"next_sends":     "Next sends:",
+	"no_next_sends":  "No planned sends",
+	"button_confirm": "Add",
+	"button_cancel":  "Cancel",
+	"prompt_remind": "Enter the date and time of a one\\-time send: `/remind 2026-12-25 09:00`, " +
+		"or just the time to get a verse at the nearest `hh:mm`: `/remind 21:00`",
+	"reminder_added":            "A verse will be sent at %s",
+	"reminder":                  "Once at %s",
+	"reminder_removed":          "One-time send cancelled",
+	"choose_schedule_for_dates": "Choose a schedule to set dates for",
+	"prompt_dates": "Enter the start and end dates of the schedule: `yyyy-mm-dd yyyy-mm-dd`\\. For example: `2027-03-15 2027-05-01`\\. " +
+		"Either date can be replaced with `-`, and a single `-` removes the limits",
+	"dates_set":             "Schedule saved: %s",
+	"dates_from_to":         "from %s to %s",
+	"dates_from":            "from %s",
+	"dates_to":              "until %s",
+	"button_solar":          "By sunrise or sunset",
+	"prompt_solar":          "Choose with a button or write, for example: \"30 minutes after sunrise\", \"1 hour before sunset\"",
+	"prompt_solar_location": "To calculate sunrise and sunset times, send your location. The bot will store its coordinates and use them only for this schedule. Use /forgetlocation to delete them together with such schedules",
+	"button_share_location": "Send location",
+	"location_saved":        "Location saved",
+	"location_forgotten":    "The stored location and the sunrise and sunset schedules were deleted",
+	"solar_removed":         "Sun schedule removed",
+	"solar_at_sunrise":      "At sunrise",
+	"solar_at_sunset":       "At sunset",
+	"solar_after_sunrise":   "%s after sunrise",
+	"solar_before_sunrise":  "%s before sunrise",
+	"solar_after_sunset":    "%s after sunset",
+	"solar_before_sunset":   "%s before sunset",
+	"schedules_cleared":     "Schedules cleared",
+	"schedule_exists":       "This schedule is already set",
+	"schedule_added":        "Schedule added",
+
+	"prompt_timezone_location": "Send your location, enter a time zone [name](https://en.wikipedia.org/wiki/List_of_tz_database_time_zones) " +
+		"\\(For example: `Europe/London`\\), or choose the offset from UTC \\(For example: `UTC+1`\\)",
+	"prompt_timezone": "Enter a time zone [name](https://en.wikipedia.org/wiki/List_of_tz_database_time_zones) " +
+		"\\(For example: `Europe/London`\\), or choose the offset from UTC \\(For example: `UTC+1`\\)",
+	"button_location":  "Detect time zone from location",
+	"current_timezone": "Current time zone: `%s`",
+	"timezone_by_location_failed": "Could not detect the time zone from the location\\. You can try again, or send a time zone " +
+		"[name](https://en.wikipedia.org/wiki/List_of_tz_database_time_zones) " +
+		"\\(For example: `Europe/London`\\)\\.",
+	"timezone_failed": "Could not detect the time zone\\. You can try again\\. Time zone names are listed " +
+		"[here](https://en.wikipedia.org/wiki/List_of_tz_database_time_zones)\\. " +
+		"Examples: `Europe/London`, `America/Los_Angeles`\\.",
+	"timezone_set":            "Time zone `%s` set\\. ",
+	"timezone_schedule_kept":  "The current schedule will follow the new time zone\\.",
+	"timezone_schedules_kept": "The current schedules will follow the new time zone\\.",
+
+	"choose_language": "Choose a language",
+	"language_set":    "Language set to English",
+
+	"choose_calendar":          "Choose the church calendar for /feast and the readings of the day. Current: %s",
+	"calendar_orthodox":        "Orthodox",
+	"calendar_western":         "Western",
+	"calendar_set":             "Calendar set to %s",
+	"choose_verse_source":      "Which verses should the schedules send? Current: %s",
+	"verse_source_random":      "Random verse",
+	"verse_source_calendar":    "Reading of the day by the calendar",
+	"verse_source_set":         "Scheduled verses: %s",
+	"feast_today":              "Today is %s\n\n%s",
+	"feast_next":               "Next feast: %s, %s (%s)\n\n%s",
+	"feast_date":               "%[2]s %[1]d",
+	"feast_not_found":          "Could not find the next feast",
+	"feast_nativity":           "the Nativity of Christ",
+	"feast_theophany":          "Theophany",
+	"feast_meeting":            "the Meeting of the Lord",
+	"feast_annunciation":       "the Annunciation",
+	"feast_palm_sunday":        "Palm Sunday",
+	"feast_pascha":             "Pascha",
+	"feast_ascension":          "the Ascension",
+	"feast_trinity":            "Holy Trinity Day",
+	"feast_transfiguration":    "the Transfiguration",
+	"feast_dormition":          "the Dormition of the Theotokos",
+	"feast_nativity_theotokos": "the Nativity of the Theotokos",
+	"feast_exaltation":         "the Exaltation of the Cross",
+	"feast_entry_theotokos":    "the Entry of the Theotokos into the Temple",
+	"feast_epiphany":           "Epiphany",
+	"feast_presentation":       "the Presentation of the Lord",
+	"feast_ash_wednesday":      "Ash Wednesday",
+	"feast_good_friday":        "Good Friday",
+	"feast_easter":             "Easter",
+	"feast_pentecost":          "Pentecost",
+	"feast_christmas":          "Christmas",
+
+	"start_greeting": "Welcome! I am a bot that sends random verses from the Bible. For example:\n\n",
+	"start_commands": "\n\nTo get a random verse, use the /random command. A one-time send at a chosen time can be ordered with /remind.\n\n" +
+		"You can set up schedules for random verses with /getregular, /addregular, /editregular, /removeregular, /clearregular, /setdates, /pause, /resume.\n\n" +
+		"Church feasts: /feast. The calendar and the verse source for schedules: /calendar, /source.\n\n" +
+		"The language can be changed with /language.\n\n",
+	"start_timezone": "The default time zone is `Europe/Moscow` \\(UTC\\+3\\)\\. " +
+		"You can send your location to detect your time zone, enter its name, choose the offset from UTC, " +
+		"or use /cancel to keep `Europe/Moscow`\\.\n\n" +
+		"Use /gettimezone and /settimezone to view and change the time zone\\.",
+
+	"cron_every_minute":       "Every minute",
+	"cron_every_day_at":       "Every day at %s:%s",
+	"cron_weekday_at":         "%s at %s:%s",
+	"weekday_0":               "Every Sunday",
+	"weekday_1":               "Every Monday",
+	"weekday_2":               "Every Tuesday",
+	"weekday_3":               "Every Wednesday",
+	"weekday_4":               "Every Thursday",
+	"weekday_5":               "Every Friday",
+	"weekday_6":               "Every Saturday",
+	"random_time":             "%s at a random time from %s to %s",
+	"random_time_short":       "Randomly from %s to %s",
+	"random_time_count":       "%s, %s at random times from %s to %s",
+	"random_time_short_count": "Randomly %s from %s to %s",
+
+	"cron_every_day":       "every day",
+	"cron_every_hour":      "every hour",
+	"cron_every_hour_at":   "Every hour at :%02d",
+	"cron_at":              "at %s",
+	"cron_from_to":         "from %s to %s",
+	"cron_on_work_days":    "on weekdays",
+	"cron_on_weekends":     "on weekends",
+	"cron_on_weekdays":     "on %s",
+	"cron_month_day":       "on day %s of the month",
+	"cron_month_days":      "on days %s of the month",
+	"cron_month_day_range": "on days %d to %d of the month",
+	"cron_date":            "on %[2]s %[1]d",
+	"cron_in_months":       "in %s",
+	"cron_month_range":     "from %s to %s",
+	"cron_or":              "or",
+	"list_and":             " and ",
+	"weekday_plural_0":     "Sundays",
+	"weekday_plural_1":     "Mondays",
+	"weekday_plural_2":     "Tuesdays",
+	"weekday_plural_3":     "Wednesdays",
+	"weekday_plural_4":     "Thursdays",
+	"weekday_plural_5":     "Fridays",
+	"weekday_plural_6":     "Saturdays",
+	"weekday_from_0":       "from Sunday",
+	"weekday_from_1":       "from Monday",
+	"weekday_from_2":       "from Tuesday",
+	"weekday_from_3":       "from Wednesday",
+	"weekday_from_4":       "from Thursday",
+	"weekday_from_5":       "from Friday",
+	"weekday_from_6":       "from Saturday",
+	"weekday_to_0":         "to Sunday",
+	"weekday_to_1":         "to Monday",
+	"weekday_to_2":         "to Tuesday",
+	"weekday_to_3":         "to Wednesday",
+	"weekday_to_4":         "to Thursday",
+	"weekday_to_5":         "to Friday",
+	"weekday_to_6":         "to Saturday",
+	"month_nom_1":          "January",
+	"month_nom_2":          "February",
+	"month_nom_3":          "March",
+	"month_nom_4":          "April",
+	"month_nom_5":          "May",
+	"month_nom_6":          "June",
+	"month_nom_7":          "July",
+	"month_nom_8":          "August",
+	"month_nom_9":          "September",
+	"month_nom_10":         "October",
+	"month_nom_11":         "November",
+	"month_nom_12":         "December",
+	"month_gen_1":          "January",
+	"month_gen_2":          "February",
+	"month_gen_3":          "March",
+	"month_gen_4":          "April",
+	"month_gen_5":          "May",
+	"month_gen_6":          "June",
+	"month_gen_7":          "July",
+	"month_gen_8":          "August",
+	"month_gen_9":          "September",
+	"month_gen_10":         "October",
+	"month_gen_11":         "November",
+	"month_gen_12":         "December",
+	"month_prep_1":         "January",
+	"month_prep_2":         "February",
+	"month_prep_3":         "March",
+	"month_prep_4":         "April",
+	"month_prep_5":         "May",
+	"month_prep_6":         "June",
+	"month_prep_7":         "July",
+	"month_prep_8":         "August",
+	"month_prep_9":         "September",
+	"month_prep_10":        "October",
+	"month_prep_11":        "November",
+	"month_prep_12":        "December",
+}
+
+var pluralMessagesEn = map[string][]string{
+	"days_until":           {"in %d day", "in %d days", "in %d days"},
+	"hours_count":          {"%d hour", "%d hours", "%d hours"},
+	"minutes_count":        {"%d minute", "%d minutes", "%d minutes"},
+	"cron_every_n_hours":   {"every %d hour", "every %d hours", "every %d hours"},
+	"cron_every_n_minutes": {"every %d minute", "every %d minutes", "every %d minutes"},
+	"cron_too_frequent": {"This schedule fires too often. Sends must be at least %d minute apart",
+		"This schedule fires too often. Sends must be at least %d minutes apart",
+		"This schedule fires too often. Sends must be at least %d minutes apart"},
+	"random_window_too_short": {"The window is too short for this many verses. Sends must be at least %d minute apart",
+		"The window is too short for this many verses. Sends must be at least %d minutes apart",
+		"The window is too short for this many verses. Sends must be at least %d minutes apart"},
+	"times_count": {"%d time", "%d times", "%d times"},
+}
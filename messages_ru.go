@@ -0,0 +1,262 @@
+package main
+
+var messagesRu = map[string]string{
+	"internal_error":      "Внутренняя ошибка бота. Уже работаем над исправлением.",
+	"invalid_format":      "Некорректный формат. Попробуйте ещё раз",
+	"operation_cancelled": "Операция отменена",
+	"random_verse_button": "Следующий случайный стих",
+
+	"prompt_natural": "Опишите расписание своими словами, например: `каждый день в 8 утра`, `по будням в 7:30 и 21:00`, " +
+		"`по воскресеньям в полдень`, `каждые 3 часа днём` или `3 раза в случайное время с 9 до 21`\\.\n\nИли выберите периодичность:",
+	"natural_understood":     "Расписание:",
+	"natural_not_understood": "Не удалось разобрать расписание. Попробуйте сформулировать иначе, например: «по будням в 7:30», или выберите вариант кнопкой выше",
+	"button_once_a_day":      "Раз в день",
+	"button_several_a_day":   "Несколько раз в день",
+	"button_once_a_week":     "Раз в неделю",
+	"button_several_a_week":  "Несколько раз в неделю",
+	"button_random_daily":    "Случайно в промежутке",
+	"button_cron":            "Задать строку cron",
+
+	"prompt_cron": "Введите строку в формате [cron](https://ru.wikipedia.org/wiki/Cron) \\(воскресенье \\- 0\\)\\. " +
+		"Можно разделить несколько расписаний с помощью точки с запятой\\. " +
+		"Например: `0 9 * * 6,0; 0 6-22/2 * * 1-5`\n",
+	"prompt_time": "Введите время в формате `чч:мм`\\. Например: `18:03`, или `07:40`",
+	"prompt_times": "Введите время в формате `чч:мм`\\. Можно разделить несколько расписаний с помощью запятой\\. " +
+		"Например: `18:03, 07:40`, или `01:00, 10:20, 23:59`",
+	"prompt_weekday_time": "Введите номер дня недели и время в формате `д чч:мм`\\. Например: `1 18:03`, или `7 07:40`\\. " +
+		"\\(1 \\- понедельник, 7 \\- воскресенье\\)",
+	"prompt_weekday_times": "Введите номер дня недели и время в формате `д чч:мм`\\. Можно разделить несколько расписаний с помощью запятой\\. " +
+		"Например: `1 18:03, 7 07:40`\\. \\(1 \\- понедельник, 7 \\- воскресенье\\)",
+	"prompt_random_window_days": "Выберите дни недели, или введите их номера через запятую\\. " +
+		"Например: `1, 3, 5`\\. \\(1 \\- понедельник, 7 \\- воскресенье\\)",
+	"days_every_day":  "Каждый день",
+	"days_work_days":  "По будням",
+	"days_weekends":   "По выходным",
+	"weekday_short_0": "Вс",
+	"weekday_short_1": "Пн",
+	"weekday_short_2": "Вт",
+	"weekday_short_3": "Ср",
+	"weekday_short_4": "Чт",
+	"weekday_short_5": "Пт",
+	"weekday_short_6": "Сб",
+	"prompt_random_window": "Введите время начала и конца промежутка для отправки в случайное время " +
+		"в формате `чч:мм, чч:мм`\\. Например: `07:40, 18:03`, или `22:00, 02:00`\\. " +
+		"Третьим числом можно указать количество стихов за промежуток: `08:00, 22:00, 3`\\.",
+
+	"current_schedules":         "Текущие расписания:",
+	"no_schedules":              "Нет регулярных расписаний",
+	"choose_schedule_to_remove": "Выберите расписание для удаления",
+	"schedule_removed":          "Расписание `%s` удалено",
+	"random_time_removed":       "Расписание случайного времени отправки удалено",
+	"choose_schedule_to_edit":   "Выберите расписание для изменения",
+	"prompt_edit_cron": "Текущее расписание: %s\\. Введите новое время в формате `чч:мм`, день недели и время в формате `д чч:мм`, " +
+		"или строку в формате cron\\. Например: `07:40`, `7 07:40` или `0 9 * * 1-5`",
+	"button_edit_window": "Изменить промежуток",
+	"button_edit_days":   "Изменить дни недели",
+	"schedule_changed":   "Расписание изменено: %s",
+	"paused":             "Расписания приостановлены",
+	"paused_until":       "Расписания приостановлены до %s",
+	"resume_hint":        "Чтобы возобновить их раньше, используйте /resume",
+	"resumed":            "Расписания возобновлены",
+	"prompt_pause": "Используйте `/pause`, чтобы приостановить расписания, пока не отправите /resume, " +
+		"или укажите срок: `/pause 14d`, `/pause 2w`, `/pause 12h` или `/pause 2026\\-12\\-25`",
+	"cron_preview":   "Ближайшие отправки по этому расписанию:",
+	"next_sends":     "Ближайшие отправки:",
+	"no_next_sends":  "Нет запланированных отправок",
+	"button_confirm": "Добавить",
+	"button_cancel":  "Отмена",
+	"prompt_remind": "Укажите дату и время однократной отправки: `/remind 2026-12-25 09:00`, " +
+		"или только время, чтобы получить стих в ближайшие `чч:мм`: `/remind 21:00`",
+	"reminder_added":            "Стих будет отправлен %s",
+	"reminder":                  "Однократно %s",
+	"reminder_removed":          "Однократная отправка отменена",
+	"choose_schedule_for_dates": "Выберите расписание, для которого нужно задать даты",
+	"prompt_dates": "Введите даты начала и окончания расписания: `гггг-мм-дд гггг-мм-дд`\\. Например: `2027-03-15 2027-05-01`\\. " +
+		"Вместо любой из дат можно поставить `-`, а один `-` снимает ограничения",
+	"dates_set":             "Расписание сохранено: %s",
+	"dates_from_to":         "с %s по %s",
+	"dates_from":            "с %s",
+	"dates_to":              "по %s",
+	"button_solar":          "По восходу или закату",
+	"prompt_solar":          "Выберите время кнопкой или напишите, например: «30 минут после восхода», «за час до заката»",
+	"prompt_solar_location": "Чтобы считать время восхода и заката, отправьте геопозицию. Бот сохранит её координаты и будет использовать их только для расчёта этого расписания. Удалить их вместе с такими расписаниями можно командой /forgetlocation",
+	"button_share_location": "Отправить геопозицию",
+	"location_saved":        "Геопозиция сохранена",
+	"location_forgotten":    "Сохранённая геопозиция и расписания по восходу и закату удалены",
+	"solar_removed":         "Расписание по солнцу удалено",
+	"solar_at_sunrise":      "На восходе",
+	"solar_at_sunset":       "На закате",
+	"solar_after_sunrise":   "Через %s после восхода",
+	"solar_before_sunrise":  "За %s до восхода",
+	"solar_after_sunset":    "Через %s после заката",
+	"solar_before_sunset":   "За %s до заката",
+	"schedules_cleared":     "Расписания очищены",
+	"schedule_exists":       "Такое расписание уже установлено",
+	"schedule_added":        "Расписание успешно добавлено",
+
+	"prompt_timezone_location": "Отправьте геопозицию, введите [название](https://en.wikipedia.org/wiki/List_of_tz_database_time_zones) часового пояса " +
+		"\\(Например: `Europe/Moscow`\\), или выберите разницу с UTC \\(Например: `UTC+1`\\)",
+	"prompt_timezone": "Введите [название](https://en.wikipedia.org/wiki/List_of_tz_database_time_zones) часового пояса " +
+		"\\(Например: `Europe/Moscow`\\), или выберите разницу с UTC \\(Например: `UTC+1`\\)",
+	"button_location":  "Определить время по геопозиции",
+	"current_timezone": "Текущий часовой пояс: `%s`",
+	"timezone_by_location_failed": "Не удалось определить часовой пояс по местоположению\\. Можете попробовать ещё раз, или отправить " +
+		"[название](https://en.wikipedia.org/wiki/List_of_tz_database_time_zones) часового пояса " +
+		"\\(Например: `Europe/Moscow`\\)\\.",
+	"timezone_failed": "Не удалось определить часовой пояс\\. Можете попробовать ещё раз\\. Названия часовых поясов можно посмотреть " +
+		"[здесь](https://en.wikipedia.org/wiki/List_of_tz_database_time_zones)\\. " +
+		"Примеры: `Europe/Moscow`, `America/Los_Angeles`\\.",
+	"timezone_set":            "Часовой пояс `%s` успешно установлен\\. ",
+	"timezone_schedule_kept":  "Текущее расписание будет считаться по новому поясу\\.",
+	"timezone_schedules_kept": "Текущие расписания будут считаться по новому поясу\\.",
+
+	"choose_language": "Выберите язык",
+	"language_set":    "Установлен русский язык",
+
+	"choose_calendar":          "Выберите церковный календарь для /feast и чтений дня. Сейчас: %s",
+	"calendar_orthodox":        "Православный",
+	"calendar_western":         "Западный",
+	"calendar_set":             "Установлен календарь: %s",
+	"choose_verse_source":      "Какие стихи отправлять по расписаниям? Сейчас: %s",
+	"verse_source_random":      "Случайный стих",
+	"verse_source_calendar":    "Чтение дня по календарю",
+	"verse_source_set":         "Стихи по расписаниям: %s",
+	"feast_today":              "Сегодня %s\n\n%s",
+	"feast_next":               "Ближайший праздник: %s, %s (%s)\n\n%s",
+	"feast_date":               "%d %s",
+	"feast_not_found":          "Не удалось найти ближайший праздник",
+	"feast_nativity":           "Рождество Христово",
+	"feast_theophany":          "Крещение Господне",
+	"feast_meeting":            "Сретение Господне",
+	"feast_annunciation":       "Благовещение Пресвятой Богородицы",
+	"feast_palm_sunday":        "Вход Господень в Иерусалим",
+	"feast_pascha":             "Пасха",
+	"feast_ascension":          "Вознесение Господне",
+	"feast_trinity":            "День Святой Троицы",
+	"feast_transfiguration":    "Преображение Господне",
+	"feast_dormition":          "Успение Пресвятой Богородицы",
+	"feast_nativity_theotokos": "Рождество Пресвятой Богородицы",
+	"feast_exaltation":         "Воздвижение Креста Господня",
+	"feast_entry_theotokos":    "Введение во храм Пресвятой Богородицы",
+	"feast_epiphany":           "Богоявление",
+	"feast_presentation":       "Сретение Господне",
+	"feast_ash_wednesday":      "Пепельная среда",
+	"feast_good_friday":        "Страстная пятница",
+	"feast_easter":             "Пасха",
+	"feast_pentecost":          "Пятидесятница",
+	"feast_christmas":          "Рождество Христово",
+
+	"start_greeting": "Добро пожаловать! Я - бот для отправки случайных стихов из Библии. Например:\n\n",
+	"start_commands": "\n\nЧтобы получить случайный стих, используйте команду /random. Однократную отправку в нужное время можно заказать командой /remind.\n\n" +
+		"Можете настроить расписания получения случайных стихов с помощью команд /getregular, /addregular, /editregular, /removeregular, /clearregular, /setdates, /pause, /resume.\n\n" +
+		"Церковные праздники: /feast. Календарь и источник стихов для расписаний: /calendar, /source.\n\n" +
+		"Язык можно сменить командой /language.\n\n",
+	"start_timezone": "По умолчанию установлен часовой пояс `Europe/Moscow` \\(UTC\\+3\\)\\. " +
+		"Можете отправить геопозицию для определения вашего часового пояса, ввести название вручную, выбрать разницу с UTC, " +
+		"или использовать /cancel для сохранения `Europe/Moscow`\\.\n\n" +
+		"Вы можете использовать команды /gettimezone и /settimezone для просмотра и смены часового пояса\\.",
+
+	"cron_every_minute":       "Каждую минуту",
+	"cron_every_day_at":       "Каждый день в %s:%s",
+	"cron_weekday_at":         "%s в %s:%s",
+	"weekday_0":               "Каждое воскресенье",
+	"weekday_1":               "Каждый понедельник",
+	"weekday_2":               "Каждый вторник",
+	"weekday_3":               "Каждую среду",
+	"weekday_4":               "Каждый четверг",
+	"weekday_5":               "Каждую пятницу",
+	"weekday_6":               "Каждую субботу",
+	"random_time":             "%s в случайное время с %s до %s",
+	"random_time_short":       "Случайно с %s до %s",
+	"random_time_count":       "%s %s в случайное время с %s до %s",
+	"random_time_short_count": "Случайно %s с %s до %s",
+
+	"cron_every_day":       "каждый день",
+	"cron_every_hour":      "каждый час",
+	"cron_every_hour_at":   "Каждый час в :%02d",
+	"cron_at":              "в %s",
+	"cron_from_to":         "с %s до %s",
+	"cron_on_work_days":    "по будням",
+	"cron_on_weekends":     "по выходным",
+	"cron_on_weekdays":     "по %s",
+	"cron_month_day":       "%s числа",
+	"cron_month_days":      "%s числа",
+	"cron_month_day_range": "с %d по %d число",
+	"cron_date":            "%d %s",
+	"cron_in_months":       "в %s",
+	"cron_month_range":     "с %s по %s",
+	"cron_or":              "или",
+	"list_and":             " и ",
+	"weekday_plural_0":     "воскресеньям",
+	"weekday_plural_1":     "понедельникам",
+	"weekday_plural_2":     "вторникам",
+	"weekday_plural_3":     "средам",
+	"weekday_plural_4":     "четвергам",
+	"weekday_plural_5":     "пятницам",
+	"weekday_plural_6":     "субботам",
+	"weekday_from_0":       "с воскресенья",
+	"weekday_from_1":       "с понедельника",
+	"weekday_from_2":       "со вторника",
+	"weekday_from_3":       "со среды",
+	"weekday_from_4":       "с четверга",
+	"weekday_from_5":       "с пятницы",
+	"weekday_from_6":       "с субботы",
+	"weekday_to_0":         "по воскресенье",
+	"weekday_to_1":         "по понедельник",
+	"weekday_to_2":         "по вторник",
+	"weekday_to_3":         "по среду",
+	"weekday_to_4":         "по четверг",
+	"weekday_to_5":         "по пятницу",
+	"weekday_to_6":         "по субботу",
+	"month_nom_1":          "январь",
+	"month_nom_2":          "февраль",
+	"month_nom_3":          "март",
+	"month_nom_4":          "апрель",
+	"month_nom_5":          "май",
+	"month_nom_6":          "июнь",
+	"month_nom_7":          "июль",
+	"month_nom_8":          "август",
+	"month_nom_9":          "сентябрь",
+	"month_nom_10":         "октябрь",
+	"month_nom_11":         "ноябрь",
+	"month_nom_12":         "декабрь",
+	"month_gen_1":          "января",
+	"month_gen_2":          "февраля",
+	"month_gen_3":          "марта",
+	"month_gen_4":          "апреля",
+	"month_gen_5":          "мая",
+	"month_gen_6":          "июня",
+	"month_gen_7":          "июля",
+	"month_gen_8":          "августа",
+	"month_gen_9":          "сентября",
+	"month_gen_10":         "октября",
+	"month_gen_11":         "ноября",
+	"month_gen_12":         "декабря",
+	"month_prep_1":         "январе",
+	"month_prep_2":         "феврале",
+	"month_prep_3":         "марте",
+	"month_prep_4":         "апреле",
+	"month_prep_5":         "мае",
+	"month_prep_6":         "июне",
+	"month_prep_7":         "июле",
+	"month_prep_8":         "августе",
+	"month_prep_9":         "сентябре",
+	"month_prep_10":        "октябре",
+	"month_prep_11":        "ноябре",
+	"month_prep_12":        "декабре",
+}
+
+var pluralMessagesRu = map[string][]string{
+	"days_until":           {"через %d день", "через %d дня", "через %d дней"},
+	"hours_count":          {"%d час", "%d часа", "%d часов"},
+	"minutes_count":        {"%d минуту", "%d минуты", "%d минут"},
+	"cron_every_n_hours":   {"каждый %d час", "каждые %d часа", "каждые %d часов"},
+	"cron_every_n_minutes": {"каждую %d минуту", "каждые %d минуты", "каждые %d минут"},
+	"cron_too_frequent": {"Расписание срабатывает слишком часто. Интервал между отправками должен быть не меньше %d минуты",
+		"Расписание срабатывает слишком часто. Интервал между отправками должен быть не меньше %d минут",
+		"Расписание срабатывает слишком часто. Интервал между отправками должен быть не меньше %d минут"},
+	"random_window_too_short": {"Окно слишком короткое для такого количества стихов. Интервал между отправками должен быть не меньше %d минуты",
+		"Окно слишком короткое для такого количества стихов. Интервал между отправками должен быть не меньше %d минут",
+		"Окно слишком короткое для такого количества стихов. Интервал между отправками должен быть не меньше %d минут"},
+	"times_count": {"%d раз", "%d раза", "%d раз"},
+}
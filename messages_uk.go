@@ -0,0 +1,262 @@
+package main
+
+var messagesUk = map[string]string{
+	"internal_error":      "Внутрішня помилка бота. Вже працюємо над виправленням.",
+	"invalid_format":      "Некоректний формат. Спробуйте ще раз",
+	"operation_cancelled": "Операцію скасовано",
+	"random_verse_button": "Наступний випадковий вірш",
+
+	"prompt_natural": "Опишіть розклад своїми словами, наприклад: `щодня о 8 ранку`, `у будні о 7:30 і 21:00`, " +
+		"`по неділях опівдні`, `кожні 3 години вдень` або `3 рази випадково з 9 до 21`\\.\n\nАбо оберіть періодичність:",
+	"natural_understood":     "Розклад:",
+	"natural_not_understood": "Не вдалося розібрати розклад. Спробуйте сформулювати інакше, наприклад: «у будні о 7:30», або оберіть варіант кнопкою вище",
+	"button_once_a_day":      "Раз на день",
+	"button_several_a_day":   "Кілька разів на день",
+	"button_once_a_week":     "Раз на тиждень",
+	"button_several_a_week":  "Кілька разів на тиждень",
+	"button_random_daily":    "Випадково в проміжку",
+	"button_cron":            "Задати рядок cron",
+
+	"prompt_cron": "Введіть рядок у форматі [cron](https://uk.wikipedia.org/wiki/Cron) \\(неділя \\- 0\\)\\. " +
+		"Можна розділити кілька розкладів крапкою з комою\\. " +
+		"Наприклад: `0 9 * * 6,0; 0 6-22/2 * * 1-5`\n",
+	"prompt_time": "Введіть час у форматі `гг:хх`\\. Наприклад: `18:03`, або `07:40`",
+	"prompt_times": "Введіть час у форматі `гг:хх`\\. Можна розділити кілька розкладів комою\\. " +
+		"Наприклад: `18:03, 07:40`, або `01:00, 10:20, 23:59`",
+	"prompt_weekday_time": "Введіть номер дня тижня і час у форматі `д гг:хх`\\. Наприклад: `1 18:03`, або `7 07:40`\\. " +
+		"\\(1 \\- понеділок, 7 \\- неділя\\)",
+	"prompt_weekday_times": "Введіть номер дня тижня і час у форматі `д гг:хх`\\. Можна розділити кілька розкладів комою\\. " +
+		"Наприклад: `1 18:03, 7 07:40`\\. \\(1 \\- понеділок, 7 \\- неділя\\)",
+	"prompt_random_window_days": "Оберіть дні тижня, або введіть їхні номери через кому\\. " +
+		"Наприклад: `1, 3, 5`\\. \\(1 \\- понеділок, 7 \\- неділя\\)",
+	"days_every_day":  "Щодня",
+	"days_work_days":  "У будні",
+	"days_weekends":   "У вихідні",
+	"weekday_short_0": "Нд",
+	"weekday_short_1": "Пн",
+	"weekday_short_2": "Вт",
+	"weekday_short_3": "Ср",
+	"weekday_short_4": "Чт",
+	"weekday_short_5": "Пт",
+	"weekday_short_6": "Сб",
+	"prompt_random_window": "Введіть час початку і кінця проміжку для надсилання у випадковий час " +
+		"у форматі `гг:хх, гг:хх`\\. Наприклад: `07:40, 18:03`, або `22:00, 02:00`\\. " +
+		"Третім числом можна вказати кількість віршів за проміжок: `08:00, 22:00, 3`\\.",
+
+	"current_schedules":         "Поточні розклади:",
+	"no_schedules":              "Немає регулярних розкладів",
+	"choose_schedule_to_remove": "Оберіть розклад для видалення",
+	"schedule_removed":          "Розклад `%s` видалено",
+	"random_time_removed":       "Розклад випадкового часу надсилання видалено",
+	"choose_schedule_to_edit":   "Оберіть розклад для зміни",
+	"prompt_edit_cron": "Поточний розклад: %s\\. Введіть новий час у форматі `гг:хх`, день тижня і час у форматі `д гг:хх`, " +
+		"або рядок у форматі cron\\. Наприклад: `07:40`, `7 07:40` або `0 9 * * 1-5`",
+	"button_edit_window": "Змінити проміжок",
+	"button_edit_days":   "Змінити дні тижня",
+	"schedule_changed":   "Розклад змінено: %s",
+	"paused":             "Розклади призупинено",
+	"paused_until":       "Розклади призупинено до %s",
+	"resume_hint":        "Щоб відновити їх раніше, скористайтеся /resume",
+	"resumed":            "Розклади відновлено",
+	"prompt_pause": "Скористайтеся `/pause`, щоб призупинити розклади, доки не надішлете /resume, " +
+		"або вкажіть термін: `/pause 14d`, `/pause 2w`, `/pause 12h` або `/pause 2026\\-12\\-25`",
+	"cron_preview":   "Найближчі надсилання за цим розкладом:",
+	"next_sends":     "Найближчі надсилання:",
+	"no_next_sends":  "Немає запланованих надсилань",
+	"button_confirm": "Додати",
+	"button_cancel":  "Скасувати",
+	"prompt_remind": "Вкажіть дату і час одноразового надсилання: `/remind 2026-12-25 09:00`, " +
+		"або лише час, щоб отримати вірш найближчої `гг:хх`: `/remind 21:00`",
+	"reminder_added":            "Вірш буде надіслано %s",
+	"reminder":                  "Одноразово %s",
+	"reminder_removed":          "Одноразове надсилання скасовано",
+	"choose_schedule_for_dates": "Оберіть розклад, для якого потрібно задати дати",
+	"prompt_dates": "Введіть дати початку і кінця розкладу: `рррр-мм-дд рррр-мм-дд`\\. Наприклад: `2027-03-15 2027-05-01`\\. " +
+		"Замість будь\\-якої з дат можна поставити `-`, а один `-` знімає обмеження",
+	"dates_set":             "Розклад збережено: %s",
+	"dates_from_to":         "з %s по %s",
+	"dates_from":            "з %s",
+	"dates_to":              "по %s",
+	"button_solar":          "За сходом чи заходом сонця",
+	"prompt_solar":          "Оберіть час кнопкою або напишіть, наприклад: «30 хвилин після сходу сонця», «за годину до заходу»",
+	"prompt_solar_location": "Щоб рахувати час сходу й заходу сонця, надішліть геопозицію. Бот збереже її координати й використовуватиме їх лише для розрахунку цього розкладу. Видалити їх разом із такими розкладами можна командою /forgetlocation",
+	"button_share_location": "Надіслати геопозицію",
+	"location_saved":        "Геопозицію збережено",
+	"location_forgotten":    "Збережену геопозицію та розклади за сонцем видалено",
+	"solar_removed":         "Розклад за сонцем видалено",
+	"solar_at_sunrise":      "На сході сонця",
+	"solar_at_sunset":       "На заході сонця",
+	"solar_after_sunrise":   "Через %s після сходу сонця",
+	"solar_before_sunrise":  "За %s до сходу сонця",
+	"solar_after_sunset":    "Через %s після заходу сонця",
+	"solar_before_sunset":   "За %s до заходу сонця",
+	"schedules_cleared":     "Розклади очищено",
+	"schedule_exists":       "Такий розклад уже встановлено",
+	"schedule_added":        "Розклад успішно додано",
+
+	"prompt_timezone_location": "Надішліть геопозицію, введіть [назву](https://en.wikipedia.org/wiki/List_of_tz_database_time_zones) часового поясу " +
+		"\\(Наприклад: `Europe/Kyiv`\\), або оберіть різницю з UTC \\(Наприклад: `UTC+2`\\)",
+	"prompt_timezone": "Введіть [назву](https://en.wikipedia.org/wiki/List_of_tz_database_time_zones) часового поясу " +
+		"\\(Наприклад: `Europe/Kyiv`\\), або оберіть різницю з UTC \\(Наприклад: `UTC+2`\\)",
+	"button_location":  "Визначити час за геопозицією",
+	"current_timezone": "Поточний часовий пояс: `%s`",
+	"timezone_by_location_failed": "Не вдалося визначити часовий пояс за місцезнаходженням\\. Можете спробувати ще раз, або надіслати " +
+		"[назву](https://en.wikipedia.org/wiki/List_of_tz_database_time_zones) часового поясу " +
+		"\\(Наприклад: `Europe/Kyiv`\\)\\.",
+	"timezone_failed": "Не вдалося визначити часовий пояс\\. Можете спробувати ще раз\\. Назви часових поясів можна переглянути " +
+		"[тут](https://en.wikipedia.org/wiki/List_of_tz_database_time_zones)\\. " +
+		"Приклади: `Europe/Kyiv`, `America/Los_Angeles`\\.",
+	"timezone_set":            "Часовий пояс `%s` успішно встановлено\\. ",
+	"timezone_schedule_kept":  "Поточний розклад рахуватиметься за новим поясом\\.",
+	"timezone_schedules_kept": "Поточні розклади рахуватимуться за новим поясом\\.",
+
+	"choose_language": "Оберіть мову",
+	"language_set":    "Встановлено українську мову",
+
+	"choose_calendar":          "Оберіть церковний календар для /feast і читань дня. Зараз: %s",
+	"calendar_orthodox":        "Православний",
+	"calendar_western":         "Західний",
+	"calendar_set":             "Встановлено календар: %s",
+	"choose_verse_source":      "Які вірші надсилати за розкладами? Зараз: %s",
+	"verse_source_random":      "Випадковий вірш",
+	"verse_source_calendar":    "Читання дня за календарем",
+	"verse_source_set":         "Вірші за розкладами: %s",
+	"feast_today":              "Сьогодні %s\n\n%s",
+	"feast_next":               "Найближче свято: %s, %s (%s)\n\n%s",
+	"feast_date":               "%d %s",
+	"feast_not_found":          "Не вдалося знайти найближче свято",
+	"feast_nativity":           "Різдво Христове",
+	"feast_theophany":          "Хрещення Господнє",
+	"feast_meeting":            "Стрітення Господнє",
+	"feast_annunciation":       "Благовіщення Пресвятої Богородиці",
+	"feast_palm_sunday":        "Вхід Господній до Єрусалима",
+	"feast_pascha":             "Великдень",
+	"feast_ascension":          "Вознесіння Господнє",
+	"feast_trinity":            "Трійця",
+	"feast_transfiguration":    "Преображення Господнє",
+	"feast_dormition":          "Успіння Пресвятої Богородиці",
+	"feast_nativity_theotokos": "Різдво Пресвятої Богородиці",
+	"feast_exaltation":         "Воздвиження Хреста Господнього",
+	"feast_entry_theotokos":    "Введення в храм Пресвятої Богородиці",
+	"feast_epiphany":           "Богоявлення",
+	"feast_presentation":       "Стрітення Господнє",
+	"feast_ash_wednesday":      "Попільна середа",
+	"feast_good_friday":        "Страсна п'ятниця",
+	"feast_easter":             "Великдень",
+	"feast_pentecost":          "П'ятдесятниця",
+	"feast_christmas":          "Різдво Христове",
+
+	"start_greeting": "Ласкаво просимо! Я - бот для надсилання випадкових віршів з Біблії. Наприклад:\n\n",
+	"start_commands": "\n\nЩоб отримати випадковий вірш, скористайтеся командою /random. Одноразове надсилання в потрібний час можна замовити командою /remind.\n\n" +
+		"Можете налаштувати розклади отримання випадкових віршів за допомогою команд /getregular, /addregular, /editregular, /removeregular, /clearregular, /setdates, /pause, /resume.\n\n" +
+		"Церковні свята: /feast. Календар і джерело віршів для розкладів: /calendar, /source.\n\n" +
+		"Мову можна змінити командою /language.\n\n",
+	"start_timezone": "За замовчуванням встановлено часовий пояс `Europe/Moscow` \\(UTC\\+3\\)\\. " +
+		"Можете надіслати геопозицію для визначення вашого часового поясу, ввести назву вручну, обрати різницю з UTC, " +
+		"або скористатися /cancel, щоб залишити `Europe/Moscow`\\.\n\n" +
+		"Ви можете використовувати команди /gettimezone і /settimezone для перегляду та зміни часового поясу\\.",
+
+	"cron_every_minute":       "Щохвилини",
+	"cron_every_day_at":       "Щодня о %s:%s",
+	"cron_weekday_at":         "%s о %s:%s",
+	"weekday_0":               "Щонеділі",
+	"weekday_1":               "Щопонеділка",
+	"weekday_2":               "Щовівторка",
+	"weekday_3":               "Щосереди",
+	"weekday_4":               "Щочетверга",
+	"weekday_5":               "Щоп'ятниці",
+	"weekday_6":               "Щосуботи",
+	"random_time":             "%s у випадковий час з %s до %s",
+	"random_time_short":       "Випадково з %s до %s",
+	"random_time_count":       "%s %s у випадковий час з %s до %s",
+	"random_time_short_count": "Випадково %s з %s до %s",
+
+	"cron_every_day":       "щодня",
+	"cron_every_hour":      "щогодини",
+	"cron_every_hour_at":   "Щогодини о :%02d",
+	"cron_at":              "о %s",
+	"cron_from_to":         "з %s до %s",
+	"cron_on_work_days":    "у будні",
+	"cron_on_weekends":     "у вихідні",
+	"cron_on_weekdays":     "по %s",
+	"cron_month_day":       "%s числа",
+	"cron_month_days":      "%s числа",
+	"cron_month_day_range": "з %d по %d число",
+	"cron_date":            "%d %s",
+	"cron_in_months":       "у %s",
+	"cron_month_range":     "з %s по %s",
+	"cron_or":              "або",
+	"list_and":             " і ",
+	"weekday_plural_0":     "неділях",
+	"weekday_plural_1":     "понеділках",
+	"weekday_plural_2":     "вівторках",
+	"weekday_plural_3":     "середах",
+	"weekday_plural_4":     "четвергах",
+	"weekday_plural_5":     "п'ятницях",
+	"weekday_plural_6":     "суботах",
+	"weekday_from_0":       "з неділі",
+	"weekday_from_1":       "з понеділка",
+	"weekday_from_2":       "з вівторка",
+	"weekday_from_3":       "із середи",
+	"weekday_from_4":       "з четверга",
+	"weekday_from_5":       "з п'ятниці",
+	"weekday_from_6":       "із суботи",
+	"weekday_to_0":         "по неділю",
+	"weekday_to_1":         "по понеділок",
+	"weekday_to_2":         "по вівторок",
+	"weekday_to_3":         "по середу",
+	"weekday_to_4":         "по четвер",
+	"weekday_to_5":         "по п'ятницю",
+	"weekday_to_6":         "по суботу",
+	"month_nom_1":          "січень",
+	"month_nom_2":          "лютий",
+	"month_nom_3":          "березень",
+	"month_nom_4":          "квітень",
+	"month_nom_5":          "травень",
+	"month_nom_6":          "червень",
+	"month_nom_7":          "липень",
+	"month_nom_8":          "серпень",
+	"month_nom_9":          "вересень",
+	"month_nom_10":         "жовтень",
+	"month_nom_11":         "листопад",
+	"month_nom_12":         "грудень",
+	"month_gen_1":          "січня",
+	"month_gen_2":          "лютого",
+	"month_gen_3":          "березня",
+	"month_gen_4":          "квітня",
+	"month_gen_5":          "травня",
+	"month_gen_6":          "червня",
+	"month_gen_7":          "липня",
+	"month_gen_8":          "серпня",
+	"month_gen_9":          "вересня",
+	"month_gen_10":         "жовтня",
+	"month_gen_11":         "листопада",
+	"month_gen_12":         "грудня",
+	"month_prep_1":         "січні",
+	"month_prep_2":         "лютому",
+	"month_prep_3":         "березні",
+	"month_prep_4":         "квітні",
+	"month_prep_5":         "травні",
+	"month_prep_6":         "червні",
+	"month_prep_7":         "липні",
+	"month_prep_8":         "серпні",
+	"month_prep_9":         "вересні",
+	"month_prep_10":        "жовтні",
+	"month_prep_11":        "листопаді",
+	"month_prep_12":        "грудні",
+}
+
+var pluralMessagesUk = map[string][]string{
+	"days_until":           {"через %d день", "через %d дні", "через %d днів"},
+	"hours_count":          {"%d годину", "%d години", "%d годин"},
+	"minutes_count":        {"%d хвилину", "%d хвилини", "%d хвилин"},
+	"cron_every_n_hours":   {"кожну %d годину", "кожні %d години", "кожні %d годин"},
+	"cron_every_n_minutes": {"кожну %d хвилину", "кожні %d хвилини", "кожні %d хвилин"},
+	"cron_too_frequent": {"Розклад спрацьовує надто часто. Інтервал між надсиланнями має бути не менше %d хвилини",
+		"Розклад спрацьовує надто часто. Інтервал між надсиланнями має бути не менше %d хвилин",
+		"Розклад спрацьовує надто часто. Інтервал між надсиланнями має бути не менше %d хвилин"},
+	"random_window_too_short": {"Вікно надто коротке для такої кількості віршів. Інтервал між надсиланнями має бути не менше %d хвилини",
+		"Вікно надто коротке для такої кількості віршів. Інтервал між надсиланнями має бути не менше %d хвилин",
+		"Вікно надто коротке для такої кількості віршів. Інтервал між надсиланнями має бути не менше %d хвилин"},
+	"times_count": {"%d раз", "%d рази", "%d разів"},
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"io/fs"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// migrationsLockKey is the id of the Postgres advisory lock held while migrations run,
+// so that instances starting together apply them one at a time.
+const migrationsLockKey = 1073741828
+
+// migrationVersion reads the version from a file name like "0002_drop_keys.sql".
+func migrationVersion(name string) (int, error) {
+	prefix, _, _ := strings.Cut(name, "_")
+	return strconv.Atoi(prefix)
+}
+
+// runMigrations applies the embedded migrations missing from schema_migrations in
+// version order, each one in its own transaction.
+func runMigrations() error {
+	ctx := context.Background()
+	conn, err := database.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.ExecContext(ctx, "select pg_advisory_lock($1);", migrationsLockKey)
+	if err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, "select pg_advisory_unlock($1);", migrationsLockKey)
+	_, err = conn.ExecContext(ctx, "create table if not exists schema_migrations ("+
+		"version int primary key, name varchar(100) not null, applied_at timestamptz not null default now());")
+	if err != nil {
+		return err
+	}
+	rows, err := conn.QueryContext(ctx, "select version from schema_migrations;")
+	if err != nil {
+		return err
+	}
+	applied := []int{}
+	for rows.Next() {
+		var version int
+		err = rows.Scan(&version)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		applied = append(applied, version)
+	}
+	rows.Close()
+	entries, err := fs.ReadDir(migrationsFS, "migrations")
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		version, err := migrationVersion(entry.Name())
+		if err != nil {
+			return err
+		}
+		if slices.Contains(applied, version) {
+			continue
+		}
+		script, err := migrationsFS.ReadFile("migrations/" + entry.Name())
+		if err != nil {
+			return err
+		}
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(string(script))
+		if err == nil {
+			_, err = tx.Exec("insert into schema_migrations (version, name) values ($1, $2);", version, entry.Name())
+		}
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		err = tx.Commit()
+		if err != nil {
+			return err
+		}
+		println("applied migration", entry.Name())
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+create table if not exists chat (
+    id bigint primary key,
+    type int not null,
+    message_status int not null default 0,
+    timezone varchar(50) not null default '',
+    language varchar(5) not null default '',
+    pending_input text not null default '',
+    paused boolean not null default false,
+    paused_until timestamptz,
+    latitude double precision,
+    longitude double precision,
+    calendar varchar(10) not null default '',
+    verse_source varchar(10) not null default ''
+);
+
+create table if not exists verses_cron (
+    chat_id bigint not null references chat(id),
+    cron varchar(30),
+    last_sent timestamptz not null default now(),
+    starts_on date,
+    ends_on date,
+    next_fire_at timestamptz,
+    unique(chat_id, cron)
+);
+
+create table if not exists random_time_verses (
+    id int primary key,
+    chat_id bigint not null references chat(id),
+    weekday int not null default -1,
+    start_time int not null,
+    duration int not null,
+    count int not null default 1,
+    starts_on date,
+    ends_on date,
+    unique(chat_id, weekday, start_time, duration)
+);
+
+create table if not exists next_sends (
+    random_time_id int not null references random_time_verses(id) on delete cascade,
+    timestamp timestamptz not null,
+    unique(random_time_id, timestamp)
+);
+
+create index if not exists next_sends_timestamp on next_sends(timestamp);
+
+create table if not exists reminders (
+    id serial primary key,
+    chat_id bigint not null references chat(id),
+    send_at timestamptz not null
+);
+
+create index if not exists reminders_send_at on reminders(send_at);
+
+create table if not exists solar_verses (
+    id serial primary key,
+    chat_id bigint not null references chat(id),
+    event int not null,
+    offset_minutes int not null,
+    next_fire_at timestamptz,
+    unique(chat_id, event, offset_minutes)
+);
+
+create table if not exists stats (
+    date date not null,
+    name varchar(30) not null,
+    count int not null,
+    unique(date, name)
+);
+
+create table if not exists stats_list_chats (
+    date date not null,
+    name varchar(30) not null,
+    chat_id bigint not null,
+    unique(date, name, chat_id)
+);
+
+create table if not exists processed_updates (
+    update_id bigint primary key,
+    received_at timestamptz not null default now()
+);
+
+create table if not exists keys (
+    name varchar(30) primary key,
+    min_key int not null
+);
+
+insert into keys(name, min_key) values ('random_time_verses', 1) on conflict do nothing;
+
+-- Databases created by hand from earlier versions of createdb.sql.
+alter table chat add column if not exists language varchar(5) not null default '';
+alter table chat add column if not exists pending_input text not null default '';
+alter table chat alter column pending_input type text;
+alter table chat add column if not exists paused boolean not null default false;
+alter table chat add column if not exists paused_until timestamptz;
+alter table chat add column if not exists latitude double precision;
+alter table chat add column if not exists longitude double precision;
+alter table chat add column if not exists calendar varchar(10) not null default '';
+alter table chat add column if not exists verse_source varchar(10) not null default '';
+
+alter table verses_cron add column if not exists last_sent timestamptz not null default now();
+alter table verses_cron add column if not exists starts_on date;
+alter table verses_cron add column if not exists ends_on date;
+alter table verses_cron add column if not exists next_fire_at timestamptz;
+
+alter table random_time_verses add column if not exists count int not null default 1;
+alter table random_time_verses add column if not exists starts_on date;
+alter table random_time_verses add column if not exists ends_on date;
+do $$
+begin
+    if not exists (select 1 from pg_constraint where conrelid = 'random_time_verses'::regclass and contype = 'u') then
+        alter table random_time_verses add unique(chat_id, weekday, start_time, duration);
+    end if;
+end $$;
+
+alter table solar_verses add column if not exists next_fire_at timestamptz;
+
+create index if not exists verses_cron_next_fire_at on verses_cron(next_fire_at);
+create index if not exists solar_verses_next_fire_at on solar_verses(next_fire_at);
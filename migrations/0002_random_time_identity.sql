@@ -0,0 +1,8 @@
+-- Random time schedule ids come from an identity column instead of the keys table.
+alter table random_time_verses alter column id add generated by default as identity;
+
+select setval(pg_get_serial_sequence('random_time_verses', 'id'),
+    greatest((select max(id) + 1 from random_time_verses), (select min_key from keys where name = 'random_time_verses'), 1),
+    false);
+
+drop table keys;
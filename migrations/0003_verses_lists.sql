@@ -0,0 +1,26 @@
+-- Verse lists, moved from versesLists.json. Lists are imported from the file at startup
+-- while the verses_lists table is empty.
+create table if not exists verses_lists (
+    id serial primary key,
+    title text not null,
+    owner_id bigint not null default 0,
+    public boolean not null default false
+);
+
+create table if not exists verses_list_entries (
+    id serial primary key,
+    list_id int not null references verses_lists(id) on delete cascade,
+    book int not null,
+    chapter int not null,
+    verses int[] not null
+);
+
+create index if not exists verses_list_entries_list_id on verses_list_entries(list_id);
+
+-- Chats other than the owner that can read the list, or also edit it if can_write is set.
+create table if not exists verses_list_access (
+    list_id int not null references verses_lists(id) on delete cascade,
+    chat_id bigint not null,
+    can_write boolean not null,
+    primary key(list_id, chat_id)
+);
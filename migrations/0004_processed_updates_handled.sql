@@ -0,0 +1,5 @@
+-- Updates are claimed when they arrive and marked handled by the worker that handled them.
+-- A claim that was never handled can be taken again by a redelivery of the update.
+alter table processed_updates add column handled_at timestamptz;
+
+update processed_updates set handled_at = received_at;
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var errNaturalSchedule = errors.New("schedule phrase not understood")
+
+// NaturalSchedule is the result of parsing a schedule described in words:
+// either a list of crons or a random time window.
+type NaturalSchedule struct {
+	Crons      []string
+	RandomTime *RandomTimeVerse
+}
+
+var naturalTokenRegexp = regexp.MustCompile(`\d{1,2}[:.]\d{2}|\d+|[\p{L}']+|-`)
+
+// Word stems are matched by prefix, so one entry covers every grammatical case.
+var naturalWeekDayStems = [][]string{
+	{"воскрес", "неділ", "нед", "sunday", "sun"},
+	{"понедельн", "понеділ", "monday", "mon"},
+	{"вторн", "вівтор", "tuesday", "tue"},
+	{"сред", "серед", "wednesday", "wed"},
+	{"четверг", "четвер", "thursday", "thu"},
+	{"пятниц", "п'ятниц", "friday", "fri"},
+	{"суббот", "субот", "saturday", "sat"},
+}
+
+var naturalEveryWords = []string{"каждые", "каждый", "каждую", "каждое", "каждого", "every", "кожні", "кожен", "кожну", "кожного"}
+var naturalHourUnits = []string{"час", "часа", "часов", "hour", "hours", "h", "годину", "години", "годин"}
+var naturalMinuteUnits = []string{"минуту", "минуты", "минут", "minute", "minutes", "min", "хвилину", "хвилини", "хвилин"}
+var naturalTimesUnits = []string{"раз", "раза", "times", "time", "рази", "разів"}
+var naturalRangeStarts = []string{"с", "со", "from", "з", "із", "від"}
+var naturalRangeEnds = []string{"до", "по", "to", "till", "until"}
+var naturalFillers = []string{"в", "во", "у", "и", "і", "й", "та", "а", "на", "по", "at", "on", "in", "the", "and", "a", "an",
+	"o", "о", "об", "of", "day", "days", "дни", "дням", "дні", "днях", "время", "час", "часов", "часа", "o'clock"}
+
+// Parts of the day used with intervals and random times, in minutes from midnight.
+var naturalDayParts = map[string][2]int{
+	"утром": {6 * 60, 12 * 60}, "morning": {6 * 60, 12 * 60}, "вранці": {6 * 60, 12 * 60}, "зранку": {6 * 60, 12 * 60},
+	"днем": {9 * 60, 21 * 60}, "днём": {9 * 60, 21 * 60}, "daytime": {9 * 60, 21 * 60}, "вдень": {9 * 60, 21 * 60}, "удень": {9 * 60, 21 * 60},
+	"afternoon": {12 * 60, 18 * 60},
+	"вечером":   {18 * 60, 23 * 60}, "evening": {18 * 60, 23 * 60}, "ввечері": {18 * 60, 23 * 60}, "увечері": {18 * 60, 23 * 60},
+	"ночью": {0, 6 * 60}, "night": {0, 6 * 60}, "вночі": {0, 6 * 60},
+}
+
+func naturalWordIn(word string, words []string) bool {
+	for _, w := range words {
+		if word == w {
+			return true
+		}
+	}
+	return false
+}
+
+func naturalWeekDay(word string) int {
+	for day, stems := range naturalWeekDayStems {
+		for _, stem := range stems {
+			if len(stem) <= 3 && word == stem || len(stem) > 3 && strings.HasPrefix(word, stem) {
+				return day
+			}
+		}
+	}
+	return -1
+}
+
+func naturalRandomWord(word string) bool {
+	return strings.HasPrefix(word, "случайн") || strings.HasPrefix(word, "random") ||
+		strings.HasPrefix(word, "випадков") || strings.HasPrefix(word, "рандом")
+}
+
+// naturalHourModifier applies words like "утра", "вечера" or "pm" to an hour said before them.
+func naturalHourModifier(word string, hour int) (int, bool) {
+	switch word {
+	case "утра", "ранку", "am":
+		return hour % 12, true
+	case "вечера", "вечора", "pm":
+		if hour < 12 {
+			return hour + 12, true
+		}
+		return hour, true
+	case "дня":
+		if hour < 7 {
+			return hour + 12, true
+		}
+		return hour, true
+	case "ночи", "ночі":
+		if hour == 12 {
+			return 0, true
+		}
+		return hour, true
+	}
+	return hour, false
+}
+
+func naturalClockTime(token string) (int, bool) {
+	token = strings.Replace(token, ".", ":", 1)
+	if !strings.Contains(token, ":") {
+		return 0, false
+	}
+	t, err := parseTime(token)
+	return t, err == nil
+}
+
+// parseNaturalSchedule turns phrases like "по будням в 7:30 и 21:00", "каждые 3 часа днём"
+// or "3 раза в случайное время с 9 до 21" into crons or a random time window.
+func parseNaturalSchedule(text string) (NaturalSchedule, error) {
+	tokens := naturalTokenRegexp.FindAllString(strings.ReplaceAll(strings.ToLower(text), "ё", "е"), -1)
+	if len(tokens) == 0 {
+		return NaturalSchedule{}, errNaturalSchedule
+	}
+	weekDays := 0
+	times := []int{}
+	interval := 0
+	windowStart, windowEnd := -1, -1
+	random := false
+	count := 1
+	rangeStartWeekDay := -1
+	inRange := false
+
+	// readTime reads a time at tokens[i], either "7:30" or an hour with an optional modifier.
+	readTime := func(i int) (int, int, bool) {
+		if t, ok := naturalClockTime(tokens[i]); ok {
+			if i+1 < len(tokens) {
+				if hour, ok := naturalHourModifier(tokens[i+1], t/60); ok {
+					return hour*60 + t%60, i + 1, true
+				}
+			}
+			return t, i, true
+		}
+		switch tokens[i] {
+		case "полдень", "полудень", "noon", "опівдні", "полудня":
+			return 12 * 60, i, true
+		case "полночь", "полночи", "midnight", "опівночі", "північ":
+			return 0, i, true
+		}
+		hour, err := strconv.Atoi(tokens[i])
+		if err != nil || hour < 0 || hour > 24 {
+			return 0, i, false
+		}
+		hour %= 24
+		if i+1 < len(tokens) {
+			if modified, ok := naturalHourModifier(tokens[i+1], hour); ok {
+				return modified * 60, i + 1, true
+			}
+		}
+		return hour * 60, i, true
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		next := ""
+		if i+1 < len(tokens) {
+			next = tokens[i+1]
+		}
+		switch {
+		case naturalWordIn(token, naturalEveryWords) || token == "щогодини" || token == "ежечасно" || token == "hourly":
+			if token == "щогодини" || token == "ежечасно" || token == "hourly" {
+				interval = 60
+				continue
+			}
+			n := 1
+			if number, err := strconv.Atoi(next); err == nil {
+				n = number
+				i++
+				if i+1 < len(tokens) {
+					next = tokens[i+1]
+				} else {
+					next = ""
+				}
+			}
+			if n <= 0 {
+				return NaturalSchedule{}, errNaturalSchedule
+			}
+			if naturalWordIn(next, naturalHourUnits) {
+				interval = n * 60
+				i++
+			} else if naturalWordIn(next, naturalMinuteUnits) {
+				interval = n
+				i++
+			} else if next == "день" || next == "day" || next == "дня" {
+				i++
+			} else if naturalWeekDay(next) < 0 {
+				return NaturalSchedule{}, errNaturalSchedule
+			}
+		case token == "ежедневно" || token == "daily" || token == "щодня" || token == "щоденно":
+		case strings.HasPrefix(token, "будн") || strings.HasPrefix(token, "weekday") || strings.HasPrefix(token, "робоч") || strings.HasPrefix(token, "рабоч"):
+			weekDays |= workDaysMask
+		case strings.HasPrefix(token, "выходн") || strings.HasPrefix(token, "weekend") || strings.HasPrefix(token, "вихідн"):
+			weekDays |= weekendsMask
+		case naturalWeekDay(token) >= 0:
+			day := naturalWeekDay(token)
+			if inRange && rangeStartWeekDay >= 0 {
+				// Weeks start on Monday, so "from Friday to Monday" wraps over the weekend.
+				for d := rangeStartWeekDay; ; d = (d + 1) % 7 {
+					weekDays |= 1 << d
+					if d == day {
+						break
+					}
+				}
+				rangeStartWeekDay = -1
+			} else {
+				weekDays |= 1 << day
+				if i > 0 && naturalWordIn(tokens[i-1], naturalRangeStarts) {
+					rangeStartWeekDay = day
+				}
+			}
+			inRange = false
+		case naturalRandomWord(token):
+			random = true
+		case naturalDayParts[token] != [2]int{}:
+			if windowStart < 0 {
+				windowStart, windowEnd = naturalDayParts[token][0], naturalDayParts[token][1]
+			}
+		case naturalWordIn(token, naturalRangeStarts) && next != "" && naturalWeekDay(next) < 0:
+			start, end, ok := readTime(i + 1)
+			if !ok {
+				return NaturalSchedule{}, errNaturalSchedule
+			}
+			i = end
+			if i+2 >= len(tokens) || !naturalWordIn(tokens[i+1], naturalRangeEnds) {
+				return NaturalSchedule{}, errNaturalSchedule
+			}
+			finish, end, ok := readTime(i + 2)
+			if !ok {
+				return NaturalSchedule{}, errNaturalSchedule
+			}
+			i = end
+			windowStart, windowEnd = start, finish
+		case naturalWordIn(token, naturalRangeEnds) && rangeStartWeekDay >= 0:
+			inRange = true
+		case token == "-" && rangeStartWeekDay >= 0:
+			inRange = true
+		default:
+			if number, err := strconv.Atoi(token); err == nil && naturalWordIn(next, naturalTimesUnits) {
+				if number < 1 || number > maxRandomTimeCount {
+					return NaturalSchedule{}, errNaturalSchedule
+				}
+				count = number
+				i++
+				continue
+			}
+			if t, end, ok := readTime(i); ok {
+				times = append(times, t)
+				i = end
+				continue
+			}
+			if naturalWordIn(token, naturalFillers) || naturalWordIn(token, naturalRangeStarts) || naturalWordIn(token, naturalTimesUnits) ||
+				token == "день" || token == "дня" {
+				continue
+			}
+			return NaturalSchedule{}, errNaturalSchedule
+		}
+	}
+	if weekDays == 0 || weekDays&allDaysMask == allDaysMask {
+		weekDays = everyDayMask
+	}
+
+	if random {
+		if windowStart < 0 || len(times) > 0 || interval > 0 {
+			return NaturalSchedule{}, errNaturalSchedule
+		}
+		duration := (windowEnd - windowStart + 24*60) % (24 * 60)
+		if duration == 0 {
+			return NaturalSchedule{}, errNaturalSchedule
+		}
+		if !randomWindowFits(duration, count) {
+			return NaturalSchedule{}, errRandomWindowTooShort
+		}
+		return NaturalSchedule{RandomTime: &RandomTimeVerse{
+			WeekDays:  weekDays,
+			StartTime: windowStart,
+			Duration:  duration,
+			Count:     count,
+		}}, nil
+	}
+	if count != 1 {
+		return NaturalSchedule{}, errNaturalSchedule
+	}
+
+	weekDaysField := "*"
+	if weekDays != everyDayMask {
+		days := []string{}
+		for day := 0; day < 7; day++ {
+			if weekDays&(1<<day) != 0 {
+				days = append(days, strconv.Itoa(day))
+			}
+		}
+		weekDaysField = strings.Join(days, ",")
+	}
+
+	crons := []string{}
+	if interval > 0 {
+		if len(times) > 0 {
+			return NaturalSchedule{}, errNaturalSchedule
+		}
+		if windowStart < 0 {
+			windowStart, windowEnd = 0, 24*60-1
+		}
+		if windowEnd <= windowStart {
+			return NaturalSchedule{}, errNaturalSchedule
+		}
+		if interval%60 == 0 {
+			hours := fmt.Sprintf("%d-%d/%d", windowStart/60, windowEnd/60, interval/60)
+			crons = append(crons, fmt.Sprintf("%d %s * * %s", windowStart%60, hours, weekDaysField))
+		} else if interval < 60 && 60%interval == 0 {
+			lastHour := windowEnd / 60
+			if windowEnd%60 == 0 {
+				lastHour--
+			}
+			crons = append(crons, fmt.Sprintf("*/%d %d-%d * * %s", interval, windowStart/60, lastHour, weekDaysField))
+		} else {
+			return NaturalSchedule{}, errNaturalSchedule
+		}
+	} else {
+		if len(times) == 0 {
+			return NaturalSchedule{}, errNaturalSchedule
+		}
+		// Times sharing the same minute go into one cron.
+		hoursByMinute := map[int][]string{}
+		for _, t := range times {
+			hoursByMinute[t%60] = append(hoursByMinute[t%60], strconv.Itoa(t/60))
+		}
+		minutes := []int{}
+		for minute := range hoursByMinute {
+			minutes = append(minutes, minute)
+		}
+		sort.Ints(minutes)
+		for _, minute := range minutes {
+			crons = append(crons, fmt.Sprintf("%d %s * * %s", minute, strings.Join(hoursByMinute[minute], ","), weekDaysField))
+		}
+	}
+	for i, cron := range crons {
+		normalized, err := normalizeCron(cron)
+		if err != nil {
+			return NaturalSchedule{}, err
+		}
+		crons[i] = normalized
+	}
+	return NaturalSchedule{Crons: crons}, nil
+}
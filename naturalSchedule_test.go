@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseNaturalScheduleCrons(t *testing.T) {
+	useFakeClock(t, time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC))
+	for _, test := range []struct {
+		phrase string
+		crons  []string
+	}{
+		{"каждый день в 8 утра", []string{"0 8 * * *"}},
+		{"по будням в 7:30 и 21:00", []string{"0 21 * * 1-5", "30 7 * * 1-5"}},
+		{"по воскресеньям в полдень", []string{"0 12 * * 0"}},
+		{"каждые 3 часа днём", []string{"0 9-21/3 * * *"}},
+		{"каждые 3 часа днем", []string{"0 9-21/3 * * *"}},
+		{"по выходным в 10", []string{"0 10 * * 0,6"}},
+		{"в понедельник и пятницу в 18:00", []string{"0 18 * * 1,5"}},
+		{"каждые 30 минут с 9 до 12", []string{"0,30 9-11 * * *"}},
+		{"every day at 9 pm", []string{"0 21 * * *"}},
+		{"щодня о 7", []string{"0 7 * * *"}},
+	} {
+		schedule, err := parseNaturalSchedule(test.phrase)
+		if err != nil {
+			t.Errorf("%q: %v", test.phrase, err)
+			continue
+		}
+		if schedule.RandomTime != nil || !slices.Equal(schedule.Crons, test.crons) {
+			t.Errorf("%q = %q, random %v, want %q", test.phrase, schedule.Crons, schedule.RandomTime, test.crons)
+		}
+	}
+}
+
+func TestParseNaturalScheduleRandomTimes(t *testing.T) {
+	useFakeClock(t, time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC))
+	for _, test := range []struct {
+		phrase    string
+		weekDays  int
+		startTime int
+		duration  int
+		count     int
+	}{
+		{"случайно с 8 до 22 3 раза", everyDayMask, 8 * 60, 14 * 60, 3},
+		{"в случайное время с 9 до 18 по будням", 0b0111110, 9 * 60, 9 * 60, 1},
+	} {
+		schedule, err := parseNaturalSchedule(test.phrase)
+		if err != nil {
+			t.Errorf("%q: %v", test.phrase, err)
+			continue
+		}
+		rt := schedule.RandomTime
+		if rt == nil || len(schedule.Crons) != 0 {
+			t.Errorf("%q = %q, want a random time", test.phrase, schedule.Crons)
+			continue
+		}
+		if rt.WeekDays != test.weekDays || rt.StartTime != test.startTime || rt.Duration != test.duration || rt.Count != test.count {
+			t.Errorf("%q = days %b, start %d, duration %d, count %d, want days %b, start %d, duration %d, count %d",
+				test.phrase, rt.WeekDays, rt.StartTime, rt.Duration, rt.Count,
+				test.weekDays, test.startTime, test.duration, test.count)
+		}
+	}
+}
+
+func TestParseNaturalScheduleErrors(t *testing.T) {
+	useFakeClock(t, time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC))
+	for phrase, want := range map[string]error{
+		"бла бла":                 errNaturalSchedule,
+		"каждые 5 минут":          errCronTooFrequent,
+		"случайно с 8 до 9 5 раз": errRandomWindowTooShort,
+	} {
+		_, err := parseNaturalSchedule(phrase)
+		if !errors.Is(err, want) {
+			t.Errorf("%q: got %v, want %v", phrase, err, want)
+		}
+	}
+}
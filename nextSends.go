@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+func nextCronFires(cron string, loc *time.Location, from time.Time, count int) []time.Time {
+	schedule, err := parseCron(cron)
+	if err != nil {
+		return nil
+	}
+	result := []time.Time{}
+	next := from.In(loc)
+	for i := 0; i < count; i++ {
+		next = schedule.Next(next)
+		if next.IsZero() {
+			break
+		}
+		result = append(result, next)
+	}
+	return result
+}
+
+func nextCronsFires(crons []string, loc *time.Location, from time.Time, count int) []time.Time {
+	result := []time.Time{}
+	for _, cron := range crons {
+		result = append(result, nextCronFires(cron, loc, from, count)...)
+	}
+	slices.SortFunc(result, func(a, b time.Time) int { return a.Compare(b) })
+	result = slices.CompactFunc(result, func(a, b time.Time) bool { return a.Equal(b) })
+	if len(result) > count {
+		result = result[:count]
+	}
+	return result
+}
+
+func getNextSends(chatId int64, count int) ([]time.Time, error) {
+	crons, err := storage.GetAllCrons(chatId)
+	if err != nil {
+		return nil, err
+	}
+	randomTimes, err := storage.GetAllRandomTimes(chatId)
+	if err != nil {
+		return nil, err
+	}
+	cronsDates, err := storage.GetCronsDates(chatId)
+	if err != nil {
+		return nil, err
+	}
+	reminders, err := storage.GetAllReminders(chatId)
+	if err != nil {
+		return nil, err
+	}
+	now := scheduler.Now()
+	loc := getChatLocation(chatId)
+	result := []time.Time{}
+	for _, cron := range crons {
+		dates := cronsDates[cron]
+		for _, fire := range nextCronFires(cron, loc, dates.start(now, loc), count) {
+			if dates.contains(fire, loc) {
+				result = append(result, fire)
+			}
+		}
+	}
+	for _, reminder := range reminders {
+		if reminder.SendAt.After(now) {
+			result = append(result, reminder.SendAt)
+		}
+	}
+	solarSends, err := nextSolarSends(chatId, now, 7)
+	if err != nil {
+		return nil, err
+	}
+	result = append(result, solarSends...)
+	for _, rt := range randomTimes {
+		for _, send := range rt.NextSends {
+			if send.After(now) {
+				result = append(result, send)
+			}
+		}
+	}
+	slices.SortFunc(result, func(a, b time.Time) int { return a.Compare(b) })
+	if len(result) > count {
+		result = result[:count]
+	}
+	return result, nil
+}
+
+func fireTimesToString(times []time.Time, loc *time.Location, lang Language) string {
+	text := ""
+	for i, t := range times {
+		if i > 0 {
+			text += "\n"
+		}
+		t = t.In(loc)
+		text += fmt.Sprintf("%s, %s", t.Format("2006-01-02 15:04"), tr(lang, fmt.Sprintf("weekday_short_%d", t.Weekday())))
+	}
+	return text
+}
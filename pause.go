@@ -0,0 +1,63 @@
+package main
+
+import (
+	"database/sql"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parsePauseEnd returns the moment the pause ends, or a null time for a pause without end.
+// Accepted arguments: empty, "14d", "2w", "12h" or a date "2026-12-25".
+func parsePauseEnd(arg string, loc *time.Location) (sql.NullTime, error) {
+	arg = strings.Trim(arg, " ")
+	if arg == "" {
+		return sql.NullTime{}, nil
+	}
+	now := scheduler.Now().In(loc)
+	date, err := time.ParseInLocation(time.DateOnly, arg, loc)
+	if err == nil {
+		if !date.After(now) {
+			return sql.NullTime{}, io.ErrShortWrite
+		}
+		return sql.NullTime{Time: date, Valid: true}, nil
+	}
+	if len(arg) < 2 {
+		return sql.NullTime{}, io.ErrShortWrite
+	}
+	count, err := strconv.Atoi(arg[:len(arg)-1])
+	if err != nil || count <= 0 || count > 366 {
+		return sql.NullTime{}, io.ErrShortWrite
+	}
+	switch arg[len(arg)-1] {
+	case 'h':
+		return sql.NullTime{Time: now.Add(time.Duration(count) * time.Hour), Valid: true}, nil
+	case 'd':
+		return sql.NullTime{Time: now.AddDate(0, 0, count), Valid: true}, nil
+	case 'w':
+		return sql.NullTime{Time: now.AddDate(0, 0, 7*count), Valid: true}, nil
+	}
+	return sql.NullTime{}, io.ErrShortWrite
+}
+
+func isChatPaused(chatId int64, at time.Time) bool {
+	paused, pausedUntil, err := storage.GetPause(chatId)
+	if err != nil {
+		return false
+	}
+	return paused && (!pausedUntil.Valid || pausedUntil.Time.After(at))
+}
+
+func pauseToString(paused bool, pausedUntil sql.NullTime, loc *time.Location, lang Language) string {
+	if !paused {
+		return ""
+	}
+	if !pausedUntil.Valid {
+		return tr(lang, "paused")
+	}
+	if !pausedUntil.Time.After(scheduler.Now()) {
+		return ""
+	}
+	return tr(lang, "paused_until", pausedUntil.Time.In(loc).Format("2006-01-02 15:04"))
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+)
+
+// parseReminderTime reads "2026-12-25 09:00", or just "09:00" for the nearest such time.
+func parseReminderTime(arg string, loc *time.Location, now time.Time) (time.Time, error) {
+	fields := strings.Fields(arg)
+	now = now.In(loc)
+	var sendAt time.Time
+	switch len(fields) {
+	case 1:
+		t, err := parseTime(fields[0])
+		if err != nil {
+			return time.Time{}, err
+		}
+		sendAt = time.Date(now.Year(), now.Month(), now.Day(), t/60, t%60, 0, 0, loc)
+		if !sendAt.After(now) {
+			sendAt = time.Date(now.Year(), now.Month(), now.Day()+1, t/60, t%60, 0, 0, loc)
+		}
+	case 2:
+		date, err := time.ParseInLocation(time.DateOnly, fields[0], loc)
+		if err != nil {
+			return time.Time{}, err
+		}
+		t, err := parseTime(fields[1])
+		if err != nil {
+			return time.Time{}, err
+		}
+		sendAt = time.Date(date.Year(), date.Month(), date.Day(), t/60, t%60, 0, 0, loc)
+	default:
+		return time.Time{}, io.ErrShortWrite
+	}
+	if !sendAt.After(now) {
+		return time.Time{}, io.ErrShortWrite
+	}
+	return sendAt, nil
+}
+
+func scheduleReminderJob(chatId int64, reminder Reminder) error {
+	return jobRegistry.add(sendJobKey(chatId, JobKindReminder, reminder.Id, reminder.SendAt),
+		gocron.OneTimeJob(gocron.OneTimeJobStartDateTime(reminder.SendAt)),
+		gocron.NewTask(reminderTask, chatId, reminder.Id))
+}
+
+func reminderTask(chatId int64, reminderId int) {
+	randomVerseTask(chatId)
+	storage.RemoveReminder(chatId, reminderId)
+	jobRegistry.removeSchedule(chatId, JobKindReminder, strconv.Itoa(reminderId))
+}
+
+func addReminder(chatId int64, sendAt time.Time) error {
+	id, err := storage.AddReminder(chatId, sendAt)
+	if err != nil {
+		return err
+	}
+	return scheduleReminderJob(chatId, Reminder{id, sendAt})
+}
+
+// createReminderJobsAfterRestart schedules future reminders, sends the ones missed
+// within the catch up grace period and drops the older ones.
+func createReminderJobsAfterRestart() error {
+	chats, err := storage.GetAllChats()
+	if err != nil {
+		return err
+	}
+	now := scheduler.Now()
+	earliest := now.Add(-getCatchUpGracePeriod())
+	for _, chatId := range chats {
+		reminders, err := storage.GetAllReminders(chatId)
+		if err != nil {
+			return err
+		}
+		for _, reminder := range reminders {
+			if reminder.SendAt.After(now) {
+				err = scheduleReminderJob(chatId, reminder)
+				if err != nil {
+					println(err.Error())
+				}
+			} else if reminder.SendAt.After(earliest) {
+				println("sending missed reminder", chatId, reminder.Id)
+				reminderTask(chatId, reminder.Id)
+			} else {
+				storage.RemoveReminder(chatId, reminder.Id)
+			}
+		}
+	}
+	return nil
+}
+
+func removeReminderForChat(chatId int64, reminderId int) error {
+	err := storage.RemoveReminder(chatId, reminderId)
+	if err != nil {
+		return err
+	}
+	jobRegistry.removeSchedule(chatId, JobKindReminder, strconv.Itoa(reminderId))
+	return nil
+}
+
+func reminderToString(reminder Reminder, loc *time.Location, lang Language) string {
+	return tr(lang, "reminder", reminder.SendAt.In(loc).Format("2006-01-02 15:04"))
+}
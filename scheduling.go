@@ -0,0 +1,33 @@
+package main
+
+import (
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/jonboulle/clockwork"
+)
+
+// Scheduler is the job scheduler together with the clock it runs on. Scheduling code
+// takes the current time from it instead of time.Now, so a clockwork fake clock
+// drives both the jobs and the code that plans them, e.g. across DST transitions.
+type Scheduler interface {
+	gocron.Scheduler
+	Now() time.Time
+}
+
+type clockScheduler struct {
+	gocron.Scheduler
+	clock clockwork.Clock
+}
+
+func (s clockScheduler) Now() time.Time {
+	return s.clock.Now()
+}
+
+func newScheduler(clock clockwork.Clock, options ...gocron.SchedulerOption) (Scheduler, error) {
+	s, err := gocron.NewScheduler(append(options, gocron.WithClock(clock))...)
+	if err != nil {
+		return nil, err
+	}
+	return clockScheduler{s, clock}, nil
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/google/uuid"
+	"github.com/jonboulle/clockwork"
+)
+
+const dstTestTimezone = "Europe/Berlin"
+
+// jobRuns is a gocron monitor that reports every finished job run, so tests wait
+// for the jobs due at a time instead of sleeping.
+type jobRuns chan struct{}
+
+func (runs jobRuns) IncrementJob(id uuid.UUID, name string, tags []string, status gocron.JobStatus) {
+	if status == gocron.Success || status == gocron.Fail {
+		runs <- struct{}{}
+	}
+}
+
+func (runs jobRuns) RecordJobTiming(startTime, endTime time.Time, id uuid.UUID, name string, tags []string) {
+}
+
+// fakeScheduling runs the scheduler on a fake clock and records when the verses
+// of every chat were queued instead of sending them.
+type fakeScheduling struct {
+	clock clockwork.FakeClock
+	runs  jobRuns
+	sent  map[int64][]time.Time
+}
+
+// startFakeScheduling sets up the memory storage and a scheduler on a fake clock at start.
+func startFakeScheduling(t *testing.T, start time.Time) *fakeScheduling {
+	t.Helper()
+	var err error
+	defaultLocation, err = time.LoadLocation(defaultTimezone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statsLocation = defaultLocation
+	bible = Bible{Books: []Book{{Title: "Test", ShortTitle: "Test", Chapters: []Chapter{{"verse"}}}}}
+	storageBackend = StorageBackendMemory
+	storage = newMemoryStorage()
+	jobRegistry = &JobRegistry{jobs: make(map[JobKey]uuid.UUID)}
+	f := &fakeScheduling{
+		clock: clockwork.NewFakeClockAt(start),
+		runs:  make(jobRuns, messageQueueSize),
+		sent:  make(map[int64][]time.Time),
+	}
+	scheduler, err = newScheduler(f.clock, gocron.WithLocation(defaultLocation), gocron.WithMonitor(f.runs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheduler.Start()
+	t.Cleanup(func() {
+		scheduler.Shutdown()
+	})
+	f.collectSent()
+	return f
+}
+
+// collectSent takes the queued verses, recording the fake time they were queued at.
+func (f *fakeScheduling) collectSent() {
+	for {
+		select {
+		case m := <-messageQueue:
+			f.sent[m.ChatId] = append(f.sent[m.ChatId], f.clock.Now())
+		default:
+			return
+		}
+	}
+}
+
+// nextDueJobs returns the earliest run after now among the jobs and how many jobs are due then.
+func nextDueJobs(t *testing.T, now time.Time) (time.Time, int) {
+	t.Helper()
+	var next time.Time
+	due := 0
+	for _, job := range scheduler.Jobs() {
+		runs, err := job.NextRuns(2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, run := range runs {
+			if !run.After(now) {
+				continue
+			}
+			if next.IsZero() || run.Before(next) {
+				next, due = run, 0
+			}
+			if run.Equal(next) {
+				due++
+			}
+			break
+		}
+	}
+	return next, due
+}
+
+// runUntil moves the fake clock from one due time to the next up to end. At each one
+// it waits until all jobs due then have run, and then records the verses they queued.
+func (f *fakeScheduling) runUntil(t *testing.T, end time.Time) {
+	t.Helper()
+	for {
+		next, due := nextDueJobs(t, f.clock.Now())
+		if due == 0 || next.After(end) {
+			break
+		}
+		f.clock.Advance(next.Sub(f.clock.Now()))
+		for i := 0; i < due; i++ {
+			select {
+			case <-f.runs:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("%d of %d jobs due at %v did not run", due-i, due, next)
+			}
+		}
+		f.collectSent()
+	}
+	if end.After(f.clock.Now()) {
+		f.clock.Advance(end.Sub(f.clock.Now()))
+	}
+}
+
+func addTestChat(t *testing.T, chatId int64) {
+	t.Helper()
+	err := storage.AddChat(chatId, ChatTypePrivate, defaultLanguage)
+	if err == nil {
+		err = storage.UpdateChatData(chatId, MessageStatusDefault, dstTestTimezone)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// localTimes formats the times as wall clock times with the zone abbreviation,
+// which tells the two 02:30 apart on the day clocks go back.
+func localTimes(times []time.Time, loc *time.Location) []string {
+	result := []string{}
+	for _, at := range times {
+		result = append(result, at.In(loc).Format("15:04 MST"))
+	}
+	return result
+}
+
+func testDstDay(t *testing.T, day time.Time, morningSends []string, nightSends []string) {
+	loc, err := time.LoadLocation(dstTestTimezone)
+	if err != nil {
+		t.Skip("no time zone data:", err)
+	}
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+	end := time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, loc)
+	f := startFakeScheduling(t, start)
+
+	const morningCron, nightCron, randomWindow = 1, 2, 3
+	for _, chatId := range []int64{morningCron, nightCron, randomWindow} {
+		addTestChat(t, chatId)
+	}
+	err = addCronsForChat([]string{"0 9 * * *"}, morningCron, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 02:30 is skipped when clocks go forward and happens twice when they go back.
+	err = addCronsForChat([]string{"30 2 * * *"}, nightCron, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The window from 01:00 to 05:00 spans the switch.
+	err = addRandomTimeRegular(randomWindow, 60, 300, everyDayMask, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	randomTimes, err := storage.GetAllRandomTimes(randomWindow)
+	if err != nil || len(randomTimes) != 1 {
+		t.Fatal("random time not added", err)
+	}
+	planned := []time.Time{}
+	for _, send := range randomTimes[0].NextSends {
+		if send.Before(end) {
+			planned = append(planned, send)
+		}
+	}
+	if len(planned) != 3 {
+		t.Fatalf("planned %d sends for today, want 3: %v", len(planned), randomTimes[0].NextSends)
+	}
+	randomSends := localTimes(planned, loc)
+
+	f.runUntil(t, end)
+
+	if got := localTimes(f.sent[morningCron], loc); !slices.Equal(got, morningSends) {
+		t.Errorf("09:00 cron sent at %v, want %v", got, morningSends)
+	}
+	if got := localTimes(f.sent[nightCron], loc); !slices.Equal(got, nightSends) {
+		t.Errorf("02:30 cron sent at %v, want %v", got, nightSends)
+	}
+	if got := localTimes(f.sent[randomWindow], loc); !slices.Equal(got, randomSends) {
+		t.Errorf("random window sent at %v, want the planned %v", got, randomSends)
+	}
+	randomTimes, err = storage.GetAllRandomTimes(randomWindow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, send := range randomTimes[0].NextSends {
+		if send.Before(end) {
+			t.Errorf("send at %v is still in next_sends after it was due", send)
+		}
+	}
+	if len(randomTimes[0].NextSends) != 3 {
+		t.Errorf("%d sends planned for tomorrow, want 3", len(randomTimes[0].NextSends))
+	}
+}
+
+// On the spring forward day 02:30 does not exist, so that verse is sent right after the gap.
+func TestSchedulingOnSpringForwardDay(t *testing.T) {
+	testDstDay(t, time.Date(2026, time.March, 29, 0, 0, 0, 0, time.UTC),
+		[]string{"09:00 CEST"}, []string{"03:30 CEST"})
+}
+
+// On the fall back day 02:30 happens twice, the verse is sent at the first one only.
+func TestSchedulingOnFallBackDay(t *testing.T) {
+	testDstDay(t, time.Date(2026, time.October, 25, 0, 0, 0, 0, time.UTC),
+		[]string{"09:00 CET"}, []string{"02:30 CEST"})
+}
+
+func TestReplaceMissingCronAddsNoJob(t *testing.T) {
+	startFakeScheduling(t, time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC))
+	const chatId = 1
+	addTestChat(t, chatId)
+
+	err := replaceCronForChat(chatId, "0 9 * * *", "0 10 * * *")
+	if !errors.Is(err, errCronNotFound) {
+		t.Fatalf("replacing a missing cron returned %v, want errCronNotFound", err)
+	}
+	err = storage.ReplaceCron(chatId, "0 9 * * *", "0 10 * * *")
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("storage replaced a missing cron: %v", err)
+	}
+	if jobs := scheduler.Jobs(); len(jobs) != 0 {
+		t.Errorf("%d jobs scheduled for a missing cron", len(jobs))
+	}
+}
+
+// remindersFailingStorage fails to list reminders the given number of times, to break
+// the recreation of chat jobs halfway.
+type remindersFailingStorage struct {
+	Storage
+	failures int
+}
+
+func (s *remindersFailingStorage) GetAllReminders(chatId int64) ([]Reminder, error) {
+	if s.failures > 0 {
+		s.failures--
+		return nil, errors.New("reminders unavailable")
+	}
+	return s.Storage.GetAllReminders(chatId)
+}
+
+func TestTimezoneChangeRestoredWhenJobsFail(t *testing.T) {
+	startFakeScheduling(t, time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC))
+	const chatId = 1
+	addTestChat(t, chatId)
+	err := addCronsForChat([]string{"0 9 * * *"}, chatId, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage = &remindersFailingStorage{Storage: storage, failures: 1}
+
+	err = changeChatTimezone(chatId, "Asia/Tokyo")
+	if err == nil {
+		t.Fatal("timezone changed although the jobs could not be recreated")
+	}
+	timezone, err := storage.GetTimezone(chatId)
+	if err != nil || timezone != dstTestTimezone {
+		t.Errorf("timezone is %q after the failed change, want %q (%v)", timezone, dstTestTimezone, err)
+	}
+	if !jobRegistry.has(cronJobKey(chatId, "0 9 * * *")) {
+		t.Error("cron job missing after the failed timezone change")
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const defaultShutdownTimeout = 30 * time.Second
+
+func getShutdownTimeout() time.Duration {
+	seconds, err := strconv.Atoi(os.Getenv("SHUTDOWN_TIMEOUT"))
+	if err != nil || seconds <= 0 {
+		return defaultShutdownTimeout
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func waitWithContext(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// shutdownSchedulerWithContext stops the scheduler, but waits for the running jobs
+// no longer than the time left in ctx.
+func shutdownSchedulerWithContext(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- scheduler.Shutdown()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func gracefulShutdown(server *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), getShutdownTimeout())
+	defer cancel()
+
+	println("shutting down: stopping webhook server")
+	err := server.Shutdown(ctx)
+	if err != nil {
+		println("error stopping webhook server", err.Error())
+	}
+
+	println("shutting down: handling queued updates")
+	err = waitWithContext(ctx, stopUpdateWorkers())
+	if err != nil {
+		println("error handling queued updates", err.Error())
+	}
+
+	println("shutting down: waiting for running jobs")
+	err = shutdownSchedulerWithContext(ctx)
+	if err != nil {
+		println("error stopping scheduler", err.Error())
+	}
+
+	println("shutting down: sending queued messages")
+	err = waitWithContext(ctx, stopMessageSenders())
+	if err != nil {
+		println("error sending queued messages", err.Error())
+	}
+
+	stopLeaderElection()
+	err = storage.Close()
+	if err != nil {
+		println("error closing storage", err.Error())
+	}
+	println("shutdown complete")
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+)
+
+const maxSolarOffset = 12 * 60
+
+var errNoSolarLocation = errors.New("chat location is not set")
+var errExistingSolar = errors.New("solar schedule already exists")
+var errInvalidSolar = errors.New("invalid solar schedule")
+
+// sunTimes returns sunrise and sunset of the given date at the coordinates, computed with
+// the sunrise equation. ok is false during polar day or night, when there is no such event.
+func sunTimes(year int, month time.Month, day int, latitude float64, longitude float64) (time.Time, time.Time, bool) {
+	const j2000 = 2451545.0
+	rad := math.Pi / 180
+	date := time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+	n := math.Round(float64(date.Unix())/86400+2440587.5-j2000) + 0.0008
+	meanSolarTime := n - longitude/360
+	anomaly := math.Mod(357.5291+0.98560028*meanSolarTime, 360)
+	center := 1.9148*math.Sin(anomaly*rad) + 0.02*math.Sin(2*anomaly*rad) + 0.0003*math.Sin(3*anomaly*rad)
+	eclipticLongitude := math.Mod(anomaly+center+180+102.9372, 360)
+	transit := j2000 + meanSolarTime + 0.0053*math.Sin(anomaly*rad) - 0.0069*math.Sin(2*eclipticLongitude*rad)
+	declination := math.Asin(math.Sin(eclipticLongitude*rad) * math.Sin(23.4397*rad))
+	cosHourAngle := (math.Sin(-0.833*rad) - math.Sin(latitude*rad)*math.Sin(declination)) /
+		(math.Cos(latitude*rad) * math.Cos(declination))
+	if cosHourAngle < -1 || cosHourAngle > 1 {
+		return time.Time{}, time.Time{}, false
+	}
+	hourAngle := math.Acos(cosHourAngle) / rad
+	julianToTime := func(julian float64) time.Time {
+		return time.Unix(int64(math.Round((julian-2440587.5)*86400)), 0).UTC()
+	}
+	return julianToTime(transit - hourAngle/360), julianToTime(transit + hourAngle/360), true
+}
+
+// solarSendTime returns the send time of the schedule on the given day in loc.
+func solarSendTime(day time.Time, solar SolarVerse, latitude float64, longitude float64, loc *time.Location) (time.Time, bool) {
+	day = day.In(loc)
+	sunrise, sunset, ok := sunTimes(day.Year(), day.Month(), day.Day(), latitude, longitude)
+	if !ok {
+		return time.Time{}, false
+	}
+	event := sunrise
+	if solar.Event == SolarEventSunset {
+		event = sunset
+	}
+	return event.Add(time.Duration(solar.Offset) * time.Minute).Truncate(time.Minute), true
+}
+
+var solarAmountRegexp = regexp.MustCompile(`(\d+)\s*(\p{L}*)`)
+
+// parseSolarSchedule reads phrases like "на закате", "30 минут после восхода" or "за час до заката".
+func parseSolarSchedule(input string) (SolarVerse, error) {
+	text := strings.ToLower(input)
+	containsAny := func(words ...string) bool {
+		for _, word := range words {
+			if strings.Contains(text, word) {
+				return true
+			}
+		}
+		return false
+	}
+	var solar SolarVerse
+	switch {
+	case containsAny("восход", "рассвет", "схід", "сход", "світан", "sunrise", "dawn"):
+		solar.Event = SolarEventSunrise
+	case containsAny("закат", "заход", "захід", "sunset", "dusk"):
+		solar.Event = SolarEventSunset
+	default:
+		return SolarVerse{}, errInvalidSolar
+	}
+	offset := 0
+	for _, match := range solarAmountRegexp.FindAllStringSubmatch(text, -1) {
+		amount, _ := strconv.Atoi(match[1])
+		switch {
+		case strings.HasPrefix(match[2], "ч") || strings.HasPrefix(match[2], "h") || strings.HasPrefix(match[2], "год"):
+			offset += amount * 60
+		case match[2] == "" || strings.HasPrefix(match[2], "м") || strings.HasPrefix(match[2], "хв"):
+			offset += amount
+		default:
+			return SolarVerse{}, errInvalidSolar
+		}
+	}
+	if offset == 0 {
+		switch {
+		case containsAny("полчаса", "півгодини", "half an hour"):
+			offset = 30
+		case containsAny("час", "годину", "hour"):
+			offset = 60
+		}
+	}
+	before := containsAny(" до ", "за ", "before")
+	after := containsAny("после", "після", "через", "after")
+	if offset != 0 && before == after {
+		return SolarVerse{}, errInvalidSolar
+	}
+	if before {
+		offset = -offset
+	}
+	if offset > maxSolarOffset || offset < -maxSolarOffset {
+		return SolarVerse{}, errInvalidSolar
+	}
+	solar.Offset = offset
+	return solar, nil
+}
+
+func offsetToString(minutes int, lang Language) string {
+	parts := []string{}
+	if minutes/60 > 0 {
+		parts = append(parts, trn(lang, "hours_count", minutes/60))
+	}
+	if minutes%60 > 0 {
+		parts = append(parts, trn(lang, "minutes_count", minutes%60))
+	}
+	return strings.Join(parts, " ")
+}
+
+func solarToString(solar SolarVerse, lang Language) string {
+	event := "sunrise"
+	if solar.Event == SolarEventSunset {
+		event = "sunset"
+	}
+	switch {
+	case solar.Offset > 0:
+		return tr(lang, "solar_after_"+event, offsetToString(solar.Offset, lang))
+	case solar.Offset < 0:
+		return tr(lang, "solar_before_"+event, offsetToString(-solar.Offset, lang))
+	}
+	return tr(lang, "solar_at_"+event)
+}
+
+func solarTask(chatId int64, solarId int, send time.Time) {
+	randomVerseTask(chatId)
+	jobRegistry.remove(sendJobKey(chatId, JobKindSolar, solarId, send))
+}
+
+func addSolarForDay(day time.Time, solar SolarVerse, chatId int64, latitude float64, longitude float64) error {
+	send, ok := solarSendTime(day, solar, latitude, longitude, getChatLocation(chatId))
+	if !ok || !send.After(scheduler.Now()) {
+		return nil
+	}
+	key := sendJobKey(chatId, JobKindSolar, solar.Id, send)
+	if jobRegistry.has(key) {
+		return nil
+	}
+	return jobRegistry.add(key, gocron.OneTimeJob(gocron.OneTimeJobStartDateTime(send)),
+		gocron.NewTask(solarTask, chatId, solar.Id, send))
+}
+
+// planSolarSchedules plans today's and tomorrow's sends of all solar schedules of the chat.
+func planSolarSchedules(chatId int64) error {
+	latitude, longitude, ok, err := storage.GetChatCoordinates(chatId)
+	if err != nil || !ok {
+		return err
+	}
+	solars, err := storage.GetAllSolarSchedules(chatId)
+	if err != nil {
+		return err
+	}
+	now := scheduler.Now().In(getChatLocation(chatId))
+	for _, solar := range solars {
+		for _, day := range []time.Time{now, now.AddDate(0, 0, 1)} {
+			err = addSolarForDay(day, solar, chatId, latitude, longitude)
+			if err != nil {
+				sendErrorReport(err, "error adding solar job")
+				println(err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+func setDailySolarTasks() error {
+	chats, err := storage.GetAllChats()
+	if err != nil {
+		return err
+	}
+	for _, chatId := range chats {
+		err = planSolarSchedules(chatId)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addSolarSchedule(chatId int64, solar SolarVerse) error {
+	_, _, ok, err := storage.GetChatCoordinates(chatId)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errNoSolarLocation
+	}
+	solars, err := storage.GetAllSolarSchedules(chatId)
+	if err != nil {
+		return err
+	}
+	if slices.ContainsFunc(solars, func(s SolarVerse) bool { return s.Event == solar.Event && s.Offset == solar.Offset }) {
+		return errExistingSolar
+	}
+	_, err = storage.AddSolarSchedule(chatId, solar)
+	if err != nil {
+		return err
+	}
+	return planSolarSchedules(chatId)
+}
+
+func removeSolarSchedule(chatId int64, solarId int) error {
+	err := storage.RemoveSolarSchedule(chatId, solarId)
+	if err != nil {
+		return err
+	}
+	jobRegistry.removeSchedule(chatId, JobKindSolar, strconv.Itoa(solarId))
+	return nil
+}
+
+// forgetChatLocation removes the stored coordinates together with the schedules that need them.
+func forgetChatLocation(chatId int64) error {
+	err := storage.ForgetChatCoordinates(chatId)
+	if err != nil {
+		return err
+	}
+	jobRegistry.removeChat(chatId, JobKindSolar)
+	return nil
+}
+
+// nextSolarSends returns the send times of the chat's solar schedules in the next few days.
+func nextSolarSends(chatId int64, from time.Time, days int) ([]time.Time, error) {
+	latitude, longitude, ok, err := storage.GetChatCoordinates(chatId)
+	if err != nil || !ok {
+		return nil, err
+	}
+	solars, err := storage.GetAllSolarSchedules(chatId)
+	if err != nil {
+		return nil, err
+	}
+	loc := getChatLocation(chatId)
+	result := []time.Time{}
+	for i := 0; i < days; i++ {
+		for _, solar := range solars {
+			send, ok := solarSendTime(from.AddDate(0, 0, i), solar, latitude, longitude, loc)
+			if ok && send.After(from) {
+				result = append(result, send)
+			}
+		}
+	}
+	return result, nil
+}
+
+func solarKeyboard(lang Language) InlineKeyboardMarkup {
+	return InlineKeyboardMarkup{[][]InlineKeyboardButton{
+		{{tr(lang, "solar_at_sunrise"), fmt.Sprintf("addsolar:%d", SolarEventSunrise)},
+			{tr(lang, "solar_at_sunset"), fmt.Sprintf("addsolar:%d", SolarEventSunset)}},
+	}}
+}
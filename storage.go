@@ -0,0 +1,125 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+	"time"
+)
+
+// Storage keeps chats, their schedules and planned sends, stats and verse lists.
+type Storage interface {
+	AddChat(chatId int64, chatType TelegramChatType, language Language) error
+	GetAllChats() ([]int64, error)
+	GetMessageStatus(chatId int64) (MessageStatus, error)
+	UpdateMessageStatus(chatId int64, messageStatus MessageStatus) error
+	UpdateChatData(chatId int64, messageStatus MessageStatus, timezone string) error
+	GetPendingInput(chatId int64) (string, error)
+	UpdateMessageStatusAndPendingInput(chatId int64, messageStatus MessageStatus, pendingInput string) error
+	GetPause(chatId int64) (bool, sql.NullTime, error)
+	UpdatePause(chatId int64, paused bool, pausedUntil sql.NullTime) error
+	ClearExpiredPauses() error
+	GetTimezone(chatId int64) (string, error)
+	GetLanguage(chatId int64) (Language, error)
+	UpdateLanguage(chatId int64, language Language) error
+	GetCalendarTradition(chatId int64) (CalendarTradition, error)
+	UpdateCalendarTradition(chatId int64, tradition CalendarTradition) error
+	GetVerseSource(chatId int64) (VerseSource, error)
+	UpdateVerseSource(chatId int64, source VerseSource) error
+	GetChatCoordinates(chatId int64) (float64, float64, bool, error)
+	UpdateChatCoordinates(chatId int64, latitude float64, longitude float64) error
+	ForgetChatCoordinates(chatId int64) error
+
+	AddCron(chatId int64, cron string) error
+	GetAllCrons(chatId int64) ([]string, error)
+	GetCronsLastSent(chatId int64) (map[string]time.Time, error)
+	UpdateCronLastSent(chatId int64, cron string, lastSent time.Time) error
+	GetCronsDates(chatId int64) (map[string]DateBounds, error)
+	UpdateCronDates(chatId int64, cron string, dates DateBounds) error
+	ReplaceCron(chatId int64, oldCron string, newCron string) error
+	RemoveCron(chatId int64, cron string) error
+
+	AddRandomTime(chatId int64, randomTime RandomTimeVerse) (int, error)
+	GetAllRandomTimes(chatId int64) ([]RandomTimeVerse, error)
+	GetRandomTimeById(randomTimeId int) (RandomTimeVerse, error)
+	UpdateRandomTime(chatId int64, randomTime RandomTimeVerse) error
+	RemoveRandomTime(chatId int64, randomTimeId int) error
+	AddNextSend(randomTimeId int, send time.Time) error
+	RemoveNextSend(randomTimeId int, send time.Time) error
+	ClearOldSends() error
+
+	AddSolarSchedule(chatId int64, solar SolarVerse) (int, error)
+	GetAllSolarSchedules(chatId int64) ([]SolarVerse, error)
+	RemoveSolarSchedule(chatId int64, solarId int) error
+
+	AddReminder(chatId int64, sendAt time.Time) (int, error)
+	GetAllReminders(chatId int64) ([]Reminder, error)
+	RemoveReminder(chatId int64, reminderId int) error
+	ClearOldReminders() error
+
+	RemoveAllSchedulesForChat(chatId int64) error
+
+	// AdvanceDueCrons and AdvanceDueSolars hand up to limit schedules that are due at now,
+	// or have no next fire time, to advance and store the next fire time it returns.
+	// A schedule is never handed to two callers at once.
+	AdvanceDueCrons(now time.Time, limit int, advance func(cron DueCron) time.Time) (int, error)
+	AdvanceDueSolars(now time.Time, limit int, advance func(solar DueSolar) time.Time) (int, error)
+	ResetCronsNextFire(chatId int64) error
+	ResetSolarsNextFire(chatId int64) error
+	// TakeDueNextSends and TakeDueReminders remove up to limit sends due at now and return them.
+	TakeDueNextSends(now time.Time, limit int) ([]DueSend, error)
+	TakeDueReminders(now time.Time, limit int) ([]DueSend, error)
+
+	// ClaimUpdate returns true if the update is new, or was claimed more than
+	// updateClaimTimeout ago and never handled. Duplicates return false.
+	ClaimUpdate(updateId int64) (bool, error)
+	MarkUpdateHandled(updateId int64) error
+	// ReleaseUpdate drops the claim of an update that was not handled, so its redelivery is handled.
+	ReleaseUpdate(updateId int64) error
+	ClearOldProcessedUpdates() error
+
+	StatPlusOne(date string, stat string) error
+	StatUpdateChatsList(date string, stat string, chatId int64) error
+	GetStatsInRange(startDate string, endDate string) ([]Stats, error)
+	GetStatsListChatsInRange(startDate string, endDate string) ([]StatsListChats, error)
+
+	GetVersesList(listId int) (VersesList, error)
+
+	Close() error
+}
+
+type StorageBackend string
+
+const (
+	StorageBackendPostgres StorageBackend = "postgres"
+	// StorageBackendMemory keeps everything in the process memory, for a single instance
+	// that does not need the data after a restart, and for trying the bot out.
+	StorageBackendMemory StorageBackend = "memory"
+)
+
+var storageBackend = getStorageBackend()
+var storage Storage
+
+func getStorageBackend() StorageBackend {
+	if StorageBackend(os.Getenv("STORAGE")) == StorageBackendMemory {
+		return StorageBackendMemory
+	}
+	return StorageBackendPostgres
+}
+
+func openStorage() error {
+	if storageBackend == StorageBackendMemory {
+		memory := newMemoryStorage()
+		storage = memory
+		return memory.importVersesListsFile()
+	}
+	err := connectToDb()
+	if err != nil {
+		return err
+	}
+	err = runMigrations()
+	if err != nil {
+		return err
+	}
+	storage = postgresStorage{}
+	return importVersesListsFile()
+}
@@ -2,6 +2,10 @@ package main
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
 	"net/http"
 	"net/http/httputil"
@@ -14,6 +18,9 @@ var TelegramApiToken string = os.Getenv("TELEGRAM_API_TOKEN")
 var TelegramApiUrl string = "https://api.telegram.org/bot" + TelegramApiToken
 var UrlForWebhook = os.Getenv("URL_FOR_WEBHOOK")
 var BotName = os.Getenv("BOT_USERNAME")
+var WebhookSecretToken = os.Getenv("WEBHOOK_SECRET_TOKEN")
+
+const maxWebhookBodySize = 1 << 20
 
 type TelegramChatType string
 
@@ -45,11 +52,11 @@ type TelegramChat struct {
 }
 
 type TelegramUser struct {
-	Id           int64
-	FirstName    string
-	LastName     string
-	Username     string
-	LanguageCode string
+	Id           int64  `json:"id"`
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name"`
+	Username     string `json:"username"`
+	LanguageCode string `json:"language_code"`
 }
 
 type Location struct {
@@ -83,6 +90,7 @@ type CallbackQuery struct {
 type Webhook struct {
 	Url            string   `json:"url"`
 	AllowedUpdates []string `json:"allowed_updates"`
+	SecretToken    string   `json:"secret_token,omitempty"`
 }
 
 type WebhookResponse struct {
@@ -92,7 +100,7 @@ type WebhookResponse struct {
 }
 
 type Update struct {
-	UpdateId      int `json:"update_id"`
+	UpdateId      int64 `json:"update_id"`
 	Message       *Message
 	CallbackQuery *CallbackQuery `json:"callback_query"`
 }
@@ -152,9 +160,20 @@ func (r ReplyKeyboardRemoveType) ImplementsReplyMarkup() {}
 
 var ReplyKeyboardRemove = ReplyKeyboardRemoveType{true}
 
+// deriveWebhookSecretToken makes the secret token from the bot token, so that all
+// instances of the bot register and accept the same one.
+func deriveWebhookSecretToken() string {
+	mac := hmac.New(sha256.New, []byte(TelegramApiToken))
+	mac.Write([]byte("webhook secret token"))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
 func createWebhook() {
+	if WebhookSecretToken == "" {
+		WebhookSecretToken = deriveWebhookSecretToken()
+	}
 	client := http.Client{}
-	webhook := Webhook{UrlForWebhook, []string{}}
+	webhook := Webhook{UrlForWebhook, []string{}, WebhookSecretToken}
 	b, err := json.Marshal(webhook)
 	if err != nil {
 		panic(err)
@@ -175,6 +194,11 @@ func createWebhook() {
 	println()
 }
 
+func checkWebhookSecretToken(request *http.Request) bool {
+	token := request.Header.Get("X-Telegram-Bot-Api-Secret-Token")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(WebhookSecretToken)) == 1
+}
+
 func sendMessage(m SendMessage) {
 	client := http.Client{}
 	b, err := json.Marshal(m)
@@ -194,8 +218,8 @@ func sendMessage(m SendMessage) {
 		return
 	}
 	statsDay := time.Now().In(statsLocation).Format(time.DateOnly)
-	dbStatPlusOne(statsDay, "msg_sent")
-	dbStatUpdateChatsList(statsDay, "chats_sent", m.ChatId)
+	storage.StatPlusOne(statsDay, "msg_sent")
+	storage.StatUpdateChatsList(statsDay, "chats_sent", m.ChatId)
 }
 
 func escapingSymbols(str string) string {
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const defaultUpdateWorkersCount = 8
+const updateQueueSize = 100
+
+// updateClaimTimeout is how long a claimed update that was not handled yet, e.g. because
+// the instance stopped before handling it, keeps its redeliveries from being handled.
+const updateClaimTimeout = 2 * time.Minute
+
+var updateQueues []chan Update
+var updateQueuesClosed = false
+var updateQueuesMutex sync.RWMutex
+var updateWorkersWaitGroup sync.WaitGroup
+
+func startUpdateWorkers() {
+	workersCount, err := strconv.Atoi(os.Getenv("UPDATE_WORKERS"))
+	if err != nil || workersCount <= 0 {
+		workersCount = defaultUpdateWorkersCount
+	}
+	for i := 0; i < workersCount; i++ {
+		queue := make(chan Update, updateQueueSize)
+		updateQueues = append(updateQueues, queue)
+		updateWorkersWaitGroup.Add(1)
+		go func() {
+			defer updateWorkersWaitGroup.Done()
+			for update := range queue {
+				handleUpdateSafely(update)
+			}
+		}()
+	}
+}
+
+func stopUpdateWorkers() *sync.WaitGroup {
+	updateQueuesMutex.Lock()
+	if !updateQueuesClosed {
+		updateQueuesClosed = true
+		for _, queue := range updateQueues {
+			close(queue)
+		}
+	}
+	updateQueuesMutex.Unlock()
+	return &updateWorkersWaitGroup
+}
+
+// handleUpdateSafely handles the update and marks it handled. If handling panics,
+// the claim of the update is dropped, so a redelivery of it is handled again.
+func handleUpdateSafely(update Update) {
+	defer func() {
+		if r := recover(); r != nil {
+			err := fmt.Errorf("%v", r)
+			println("panic while handling update", update.UpdateId, err.Error())
+			sendErrorReport(err, "Ошибка при обработке обновления")
+			storage.ReleaseUpdate(update.UpdateId)
+		}
+	}()
+	handleUpdate(update)
+	storage.MarkUpdateHandled(update.UpdateId)
+}
+
+func getUpdateChatId(update Update) int64 {
+	if update.CallbackQuery != nil && update.CallbackQuery.Message != nil {
+		return update.CallbackQuery.Message.Chat.Id
+	}
+	if update.Message != nil {
+		return update.Message.Chat.Id
+	}
+	return 0
+}
+
+// enqueueUpdate sends all updates of one chat to the same worker, so they are handled
+// one after another in the order they arrived. It never waits: if the worker's queue is
+// full or the workers were stopped it returns false, and the update should be redelivered.
+func enqueueUpdate(update Update) bool {
+	updateQueuesMutex.RLock()
+	defer updateQueuesMutex.RUnlock()
+	if updateQueuesClosed {
+		return false
+	}
+	chatId := getUpdateChatId(update)
+	index := chatId % int64(len(updateQueues))
+	if index < 0 {
+		index = -index
+	}
+	select {
+	case updateQueues[index] <- update:
+		return true
+	default:
+		return false
+	}
+}
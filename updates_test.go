@@ -0,0 +1,50 @@
+package main
+
+import "testing"
+
+func TestClaimUpdate(t *testing.T) {
+	storage = newMemoryStorage()
+	const updateId = 7
+
+	claimed, err := storage.ClaimUpdate(updateId)
+	if err != nil || !claimed {
+		t.Fatalf("new update not claimed: %v", err)
+	}
+	claimed, _ = storage.ClaimUpdate(updateId)
+	if claimed {
+		t.Error("update being handled claimed again")
+	}
+	storage.ReleaseUpdate(updateId)
+	claimed, _ = storage.ClaimUpdate(updateId)
+	if !claimed {
+		t.Error("released update not claimed again")
+	}
+	storage.MarkUpdateHandled(updateId)
+	storage.ReleaseUpdate(updateId)
+	claimed, _ = storage.ClaimUpdate(updateId)
+	if claimed {
+		t.Error("handled update claimed again")
+	}
+}
+
+func TestEnqueueUpdateDoesNotWait(t *testing.T) {
+	updateQueues = []chan Update{make(chan Update, 1)}
+	updateQueuesClosed = false
+	t.Cleanup(func() {
+		updateQueues = nil
+		updateQueuesClosed = false
+	})
+	update := Update{Message: &Message{Chat: TelegramChat{Id: 1}}}
+
+	if !enqueueUpdate(update) {
+		t.Fatal("update not queued")
+	}
+	if enqueueUpdate(update) {
+		t.Error("update queued although the queue is full")
+	}
+	<-updateQueues[0]
+	stopUpdateWorkers()
+	if enqueueUpdate(update) {
+		t.Error("update queued after the workers were stopped")
+	}
+}